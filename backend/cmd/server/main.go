@@ -3,11 +3,14 @@ package main
 import (
 	"backend/internal/config"
 	infraDB "backend/internal/database"
+    "backend/internal/repository/local"
     "backend/internal/repository/mysql"
     "backend/internal/app/schema"
     appDB "backend/internal/app/database"
     "backend/internal/app/data"
     "backend/internal/app/layout"
+    "backend/internal/app/query"
+    "backend/internal/app/view"
 	"backend/internal/transport/http/routes"
 	"log"
 
@@ -20,24 +23,28 @@ func main() {
 	cfg := config.LoadConfig()
 
 	// 2. Connect to Database (Global GORM instance for now)
-	infraDB.Connect(cfg.DSN)
+	infraDB.Connect(cfg.DSN, cfg.SSH)
     
     // 3. Dependency Injection (Modern Style)
-    repo := mysql.NewMySQLRepository(infraDB.DB)
+    repo := mysql.NewMySQLRepository(infraDB.DB, cfg.ReadOnly)
+    dataDir := config.DataDir() // local metadata (layouts, views, ...)
     
     syncSvc := schema.NewSyncService(repo)
-    dbSvc := appDB.NewDatabaseService(repo)
+    dbSvc := appDB.NewDatabaseService(repo, cfg.QueryTimeout)
     dataSvc := data.NewDataService(repo)
-    layoutSvc := layout.NewLayoutService(repo)
+    layoutStore := local.NewLayoutRepository(dataDir) // shared: views delete their layouts
+    viewSvc := view.NewViewService(repo, local.NewViewRepository(dataDir), layoutStore)
+    layoutSvc := layout.NewLayoutService(repo, layoutStore, viewSvc)
+    querySvc := query.NewQueryService(local.NewQueryRepository(dataDir), dbSvc)
 
 	// 4. Initialize Fiber App
-	app := fiber.New()
+	app := fiber.New(fiber.Config{BodyLimit: cfg.BodyLimit})
 
 	// 5. Middleware
 	app.Use(cors.New())
 
 	// 6. Setup Routes (Connect Services to Handlers)
-	routes.SetupRoutes(app, syncSvc, dbSvc, dataSvc, layoutSvc, repo)
+	routes.SetupRoutes(app, syncSvc, dbSvc, dataSvc, layoutSvc, viewSvc, querySvc, repo)
 
 	// 7. Start Server
 	log.Printf("Server listening on port %s (Clean Architecture)", cfg.ServerPort)
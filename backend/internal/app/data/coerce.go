@@ -0,0 +1,232 @@
+package data
+
+import (
+	"backend/internal/domain"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006/01/02",
+	"2 Jan 2006",
+	"Jan 2, 2006",
+}
+
+var dateTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+	time.RFC3339Nano,
+	"2006/01/02 15:04:05",
+}
+
+// sqlType is the parsed form of a MySQL column type like "varchar(255)",
+// "decimal(10,2) unsigned" or "enum('a','b')".
+type sqlType struct {
+	base     string
+	length   int
+	unsigned bool
+	values   []string // enum / set members
+}
+
+func parseSQLType(t string) sqlType {
+	t = strings.ToLower(strings.TrimSpace(t))
+	st := sqlType{base: t, unsigned: strings.Contains(t, "unsigned")}
+	if i := strings.IndexByte(t, '('); i >= 0 {
+		st.base = t[:i]
+		args := t[i+1:]
+		if j := strings.LastIndexByte(args, ')'); j >= 0 {
+			args = args[:j]
+		}
+		if st.base == "enum" || st.base == "set" {
+			for _, v := range strings.Split(args, "','") {
+				st.values = append(st.values, strings.Trim(v, "'"))
+			}
+		} else if n, err := strconv.Atoi(strings.SplitN(args, ",", 2)[0]); err == nil {
+			st.length = n
+		}
+	} else if f := strings.Fields(t); len(f) > 0 {
+		st.base = f[0]
+	}
+	return st
+}
+
+// coerceValue converts an imported value (CSV text or decoded JSON) into
+// something the column accepts, or explains why it cannot.
+func coerceValue(col domain.ColumnMeta, v interface{}) (interface{}, error) {
+	if s, ok := v.(string); ok && s == "" && !isTextType(parseSQLType(col.Type).base) {
+		v = nil
+	}
+	if v == nil {
+		switch {
+		case col.Nullable || col.IsAutoIncrement:
+			return nil, nil
+		case col.Default != nil:
+			return domain.DefaultValue{}, nil
+		}
+		return nil, fmt.Errorf("%s is required", col.Name)
+	}
+
+	st := parseSQLType(col.Type)
+	text := valueText(v)
+	raw, isString := v.(string)
+	if !isString {
+		raw = text
+	}
+
+	switch st.base {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint":
+		if b, ok := v.(bool); ok {
+			if b {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+		if st.base == "tinyint" && st.length == 1 {
+			switch strings.ToLower(text) {
+			case "true", "yes", "y":
+				return int64(1), nil
+			case "false", "no", "n":
+				return int64(0), nil
+			}
+		}
+		if st.unsigned {
+			n, err := strconv.ParseUint(text, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %q is not an unsigned integer", col.Name, text)
+			}
+			return n, nil
+		}
+		n, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not an integer", col.Name, text)
+		}
+		return n, nil
+	case "decimal", "numeric":
+		// keep as text so no precision is lost on the way to MySQL
+		if _, ok := new(big.Float).SetString(text); !ok {
+			return nil, fmt.Errorf("%s: %q is not a number", col.Name, text)
+		}
+		return text, nil
+	case "float", "double", "real":
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a number", col.Name, text)
+		}
+		return f, nil
+	case "bit", "bool", "boolean":
+		switch strings.ToLower(text) {
+		case "1", "true", "yes", "y":
+			return int64(1), nil
+		case "0", "false", "no", "n":
+			return int64(0), nil
+		}
+		return nil, fmt.Errorf("%s: %q is not a boolean", col.Name, text)
+	case "year":
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a year", col.Name, text)
+		}
+		return n, nil
+	case "date":
+		t, err := parseTime(text, dateLayouts, dateTimeLayouts)
+		if err != nil {
+			t, err = fromSerialDate(text)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a date", col.Name, text)
+		}
+		return t.Format("2006-01-02"), nil
+	case "datetime", "timestamp":
+		t, err := parseTime(text, dateTimeLayouts, dateLayouts)
+		if err != nil {
+			t, err = fromSerialDate(text)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a date/time", col.Name, text)
+		}
+		return t.In(time.Local).Format("2006-01-02 15:04:05.999999"), nil
+	case "enum":
+		for _, allowed := range st.values {
+			if strings.EqualFold(allowed, text) {
+				return allowed, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: %q is not one of %s", col.Name, text, strings.Join(st.values, ", "))
+	case "json":
+		if s, ok := v.(string); ok {
+			if !json.Valid([]byte(s)) {
+				return nil, fmt.Errorf("%s: invalid JSON", col.Name)
+			}
+			return s, nil
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", col.Name, err)
+		}
+		return string(b), nil
+	case "char", "varchar":
+		if st.length > 0 && utf8.RuneCountInString(raw) > st.length {
+			return nil, fmt.Errorf("%s: value longer than %d characters", col.Name, st.length)
+		}
+		return raw, nil
+	}
+	return raw, nil
+}
+
+func isTextType(base string) bool {
+	switch base {
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext":
+		return true
+	}
+	return false
+}
+
+func valueText(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return strings.TrimSpace(x)
+	case json.Number:
+		return x.String()
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case time.Time:
+		return x.Format("2006-01-02 15:04:05.999999")
+	}
+	return fmt.Sprint(v)
+}
+
+func parseTime(text string, layouts ...[]string) (time.Time, error) {
+	for _, group := range layouts {
+		for _, layout := range group {
+			if t, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date %q", text)
+}
+
+// excelEpoch is day 0 of spreadsheet serial dates (1900 date system,
+// including Excel's phantom 29 Feb 1900)
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.Local)
+
+// fromSerialDate converts a spreadsheet serial number (days since the
+// epoch, the fraction being the time of day) as read from XLSX cells
+func fromSerialDate(text string) (time.Time, error) {
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil || f < 1 || f > 2958465 { // 9999-12-31
+		return time.Time{}, fmt.Errorf("not a serial date: %q", text)
+	}
+	days := math.Floor(f)
+	ms := math.Round((f - days) * 86400000)
+	return excelEpoch.AddDate(0, 0, int(days)).Add(time.Duration(ms) * time.Millisecond), nil
+}
@@ -0,0 +1,224 @@
+package data
+
+import (
+	"backend/internal/domain"
+	"backend/internal/sqlutil"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Export formats
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// RowWriter serializes a result set row by row
+type RowWriter interface {
+	Begin(columns []string) error
+	Row(values []interface{}) error
+	End() error
+	ContentType() string
+	Extension() string
+}
+
+// NewRowWriter returns a writer for format that writes to w
+func NewRowWriter(format string, w io.Writer) (RowWriter, error) {
+	switch format {
+	case FormatCSV, "":
+		return &csvRowWriter{w: csv.NewWriter(w)}, nil
+	case FormatJSON:
+		return &jsonRowWriter{w: w, array: true}, nil
+	case FormatNDJSON:
+		return &jsonRowWriter{w: w}, nil
+	case FormatXLSX:
+		return newXLSXRowWriter(w), nil
+	}
+	return nil, fmt.Errorf("unsupported format %q (use csv, json, ndjson or xlsx): %w", format, domain.ErrInvalidInput)
+}
+
+// exportValue turns a scanned driver value into something printable
+func exportValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case []byte:
+		return string(x)
+	case time.Time:
+		return x.Format("2006-01-02 15:04:05")
+	}
+	return v
+}
+
+type csvRowWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvRowWriter) Begin(columns []string) error { return c.w.Write(columns) }
+
+func (c *csvRowWriter) Row(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		record[i] = fmt.Sprint(exportValue(v))
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvRowWriter) End() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvRowWriter) ContentType() string { return "text/csv; charset=utf-8" }
+func (c *csvRowWriter) Extension() string   { return "csv" }
+
+// jsonRowWriter writes either a JSON array of objects or NDJSON. Objects
+// are written by hand to keep the table's column order.
+type jsonRowWriter struct {
+	w       io.Writer
+	array   bool
+	columns [][]byte
+	count   int
+}
+
+func (j *jsonRowWriter) Begin(columns []string) error {
+	j.columns = make([][]byte, len(columns))
+	for i, c := range columns {
+		key, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+		j.columns[i] = key
+	}
+	if j.array {
+		_, err := io.WriteString(j.w, "[\n")
+		return err
+	}
+	return nil
+}
+
+func (j *jsonRowWriter) Row(values []interface{}) error {
+	var b strings.Builder
+	if j.array && j.count > 0 {
+		b.WriteString(",\n")
+	}
+	b.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.Write(j.columns[i])
+		b.WriteByte(':')
+		val, err := json.Marshal(exportValue(v))
+		if err != nil {
+			return err
+		}
+		b.Write(val)
+	}
+	b.WriteByte('}')
+	if !j.array {
+		b.WriteByte('\n')
+	}
+	j.count++
+	_, err := io.WriteString(j.w, b.String())
+	return err
+}
+
+func (j *jsonRowWriter) End() error {
+	if j.array {
+		_, err := io.WriteString(j.w, "\n]\n")
+		return err
+	}
+	return nil
+}
+
+func (j *jsonRowWriter) ContentType() string {
+	if j.array {
+		return "application/json"
+	}
+	return "application/x-ndjson"
+}
+
+func (j *jsonRowWriter) Extension() string {
+	if j.array {
+		return "json"
+	}
+	return "ndjson"
+}
+
+const flushEvery = 500
+
+func (s *dataService) ExportTable(ctx context.Context, table string, q domain.DataQuery, format string) (*domain.Export, error) {
+	if _, err := NewRowWriter(format, io.Discard); err != nil {
+		return nil, err
+	}
+	stream, err := s.repo.StreamTable(ctx, table, q)
+	if err != nil {
+		return nil, err
+	}
+	return newExport(stream, format, table), nil
+}
+
+// ExportQuery streams the result of an ad-hoc query; only read statements
+// are accepted so an export can never change data.
+func (s *dataService) ExportQuery(ctx context.Context, query, format string) (*domain.Export, error) {
+	if !sqlutil.IsRead(query) {
+		return nil, fmt.Errorf("only SELECT-like statements can be exported: %w", domain.ErrInvalidInput)
+	}
+	if _, err := NewRowWriter(format, io.Discard); err != nil {
+		return nil, err
+	}
+	stream, err := s.repo.StreamQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return newExport(stream, format, "query"), nil
+}
+
+func newExport(stream domain.RowStream, format, name string) *domain.Export {
+	probe, _ := NewRowWriter(format, io.Discard)
+	return &domain.Export{
+		ContentType: probe.ContentType(),
+		Filename:    name + "." + probe.Extension(),
+		Write: func(w io.Writer) error {
+			defer stream.Close()
+			rw, _ := NewRowWriter(format, w)
+			if x, ok := rw.(*xlsxRowWriter); ok {
+				x.sheet = name
+			}
+			return writeStream(stream, rw, w)
+		},
+	}
+}
+
+// writeStream copies rows from stream to rw, flushing w regularly when it
+// is buffered so the client receives data while the query is still running.
+func writeStream(stream domain.RowStream, rw RowWriter, w io.Writer) error {
+	flusher, _ := w.(interface{ Flush() error })
+	if err := rw.Begin(stream.Columns()); err != nil {
+		return err
+	}
+	n := 0
+	for stream.Next() {
+		if err := rw.Row(stream.Values()); err != nil {
+			return err
+		}
+		n++
+		if flusher != nil && n%flushEvery == 0 {
+			if err := flusher.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return err
+	}
+	return rw.End()
+}
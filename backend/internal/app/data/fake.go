@@ -0,0 +1,326 @@
+package data
+
+import (
+	"backend/internal/domain"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	fakeFirstNames = []string{"Ava", "Liam", "Olivia", "Noah", "Emma", "Lucas", "Mia", "Ethan", "Sofia", "Mateo", "Amelia", "Leo", "Isla", "Hugo", "Zara", "Omar", "Nina", "Arjun", "Yuki", "Budi", "Sari", "Chen", "Fatima", "Diego"}
+	fakeLastNames  = []string{"Smith", "Garcia", "Nguyen", "Müller", "Rossi", "Kim", "Santoso", "Silva", "Novak", "Cohen", "Tanaka", "Okafor", "Larsen", "Dubois", "Kowalski", "Hassan", "Patel", "Lopez", "Wright", "Ivanova"}
+	fakeCities     = []string{"Jakarta", "Lisbon", "Toronto", "Osaka", "Nairobi", "Berlin", "Austin", "Lyon", "Bandung", "Melbourne", "Seville", "Krakow", "Denver", "Porto", "Surabaya"}
+	fakeCountries  = []string{"Indonesia", "Portugal", "Canada", "Japan", "Kenya", "Germany", "United States", "France", "Australia", "Spain", "Poland", "Brazil"}
+	fakeStreets    = []string{"Oak Street", "Maple Avenue", "Jalan Sudirman", "Rue de la Paix", "Harbour Road", "Hill Lane", "Station Road", "Park Avenue", "Church Street", "Mill Lane"}
+	fakeCompanies  = []string{"Acme", "Globex", "Initech", "Umbrella", "Stark", "Wayne", "Hooli", "Vandelay", "Soylent", "Tyrell", "Cyberdyne", "Wonka"}
+	fakeSuffixes   = []string{"Inc", "Ltd", "Group", "Labs", "Co", "Systems"}
+	fakeProducts   = []string{"Chair", "Lamp", "Keyboard", "Backpack", "Notebook", "Mug", "Headphones", "Bottle", "Jacket", "Monitor", "Desk", "Camera"}
+	fakeAdjectives = []string{"Compact", "Classic", "Ergonomic", "Smart", "Rustic", "Sleek", "Durable", "Premium", "Eco", "Portable"}
+	fakeColors     = []string{"red", "green", "blue", "black", "white", "orange", "purple", "teal", "gray", "yellow"}
+	fakeStatuses   = []string{"active", "inactive", "pending", "archived"}
+	fakeWords      = strings.Fields("lorem ipsum dolor sit amet consectetur adipiscing elit sed do eiusmod tempor incididunt ut labore et dolore magna aliqua enim ad minim veniam quis nostrud exercitation ullamco laboris nisi aliquip ex ea commodo consequat")
+)
+
+// valueFaker produces plausible values for one column, chosen once from
+// the column's name and type
+type valueFaker func(r *rand.Rand, seq int) interface{}
+
+// fakerFor picks a generator for col. Name hints win over the plain type
+// when the type can hold them (an "email" int column still gets numbers).
+func fakerFor(col domain.ColumnMeta) valueFaker {
+	st := parseSQLType(col.Type)
+	name := strings.ToLower(col.Name)
+	has := func(parts ...string) bool {
+		for _, p := range parts {
+			if name == p || strings.HasPrefix(name, p+"_") || strings.HasSuffix(name, "_"+p) || strings.Contains(name, "_"+p+"_") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if isTextType(st.base) {
+		var f valueFaker
+		switch {
+		case has("email", "mail") || strings.Contains(name, "email"):
+			f = func(r *rand.Rand, seq int) interface{} {
+				return fmt.Sprintf("%s.%s%d@example.com", strings.ToLower(pick(r, fakeFirstNames)), asciiLower(pick(r, fakeLastNames)), seq)
+			}
+		case has("first", "firstname", "fname", "given") || strings.Contains(name, "first_name"):
+			f = func(r *rand.Rand, _ int) interface{} { return pick(r, fakeFirstNames) }
+		case has("last", "lastname", "lname", "surname", "family") || strings.Contains(name, "last_name"):
+			f = func(r *rand.Rand, _ int) interface{} { return pick(r, fakeLastNames) }
+		case has("username", "login", "handle", "nickname"):
+			f = func(r *rand.Rand, seq int) interface{} {
+				return fmt.Sprintf("%s%d", strings.ToLower(pick(r, fakeFirstNames)), seq)
+			}
+		case has("phone", "mobile", "tel", "fax"):
+			f = func(r *rand.Rand, _ int) interface{} {
+				return fmt.Sprintf("+1-%03d-%03d-%04d", 200+r.Intn(800), r.Intn(1000), r.Intn(10000))
+			}
+		case has("city", "town"):
+			f = func(r *rand.Rand, _ int) interface{} { return pick(r, fakeCities) }
+		case has("country"):
+			f = func(r *rand.Rand, _ int) interface{} { return pick(r, fakeCountries) }
+		case has("address", "street", "addr"):
+			f = func(r *rand.Rand, _ int) interface{} {
+				return fmt.Sprintf("%d %s", 1+r.Intn(999), pick(r, fakeStreets))
+			}
+		case has("zip", "postal", "postcode", "zipcode"):
+			f = func(r *rand.Rand, _ int) interface{} { return fmt.Sprintf("%05d", r.Intn(100000)) }
+		case has("company", "organization", "organisation", "employer", "vendor", "supplier"):
+			f = func(r *rand.Rand, _ int) interface{} { return pick(r, fakeCompanies) + " " + pick(r, fakeSuffixes) }
+		case has("url", "website", "link", "homepage"):
+			f = func(r *rand.Rand, seq int) interface{} {
+				return fmt.Sprintf("https://%s.example.com/%d", strings.ToLower(pick(r, fakeCompanies)), seq)
+			}
+		case has("uuid", "guid"):
+			f = func(r *rand.Rand, _ int) interface{} { return fakeUUID(r) }
+		case has("slug", "code", "sku", "token", "key", "ref", "reference"):
+			f = func(r *rand.Rand, seq int) interface{} { return fmt.Sprintf("%s-%d", randomToken(r, 6), seq) }
+		case has("color", "colour"):
+			f = func(r *rand.Rand, _ int) interface{} { return pick(r, fakeColors) }
+		case has("status", "state"):
+			f = func(r *rand.Rand, _ int) interface{} { return pick(r, fakeStatuses) }
+		case has("gender", "sex"):
+			f = func(r *rand.Rand, _ int) interface{} { return pick(r, []string{"female", "male", "other"}) }
+		case has("ip", "ip_address"):
+			f = func(r *rand.Rand, _ int) interface{} {
+				return fmt.Sprintf("10.%d.%d.%d", r.Intn(256), r.Intn(256), 1+r.Intn(254))
+			}
+		case has("password", "hash", "secret"):
+			f = func(r *rand.Rand, _ int) interface{} { return randomToken(r, 32) }
+		case has("title", "subject", "headline"):
+			f = func(r *rand.Rand, _ int) interface{} { return capitalize(words(r, 3+r.Intn(4))) }
+		case has("product", "item"):
+			f = func(r *rand.Rand, _ int) interface{} { return pick(r, fakeAdjectives) + " " + pick(r, fakeProducts) }
+		case has("description", "desc", "notes", "note", "bio", "comment", "comments", "body", "content", "text", "summary", "message"):
+			f = func(r *rand.Rand, _ int) interface{} { return capitalize(words(r, 8+r.Intn(16))) + "." }
+		case has("name", "full_name", "fullname", "display_name", "customer", "author", "contact"):
+			f = func(r *rand.Rand, _ int) interface{} { return pick(r, fakeFirstNames) + " " + pick(r, fakeLastNames) }
+		default:
+			f = func(r *rand.Rand, _ int) interface{} { return words(r, 1+r.Intn(3)) }
+		}
+		if st.length > 0 {
+			inner := f
+			f = func(r *rand.Rand, seq int) interface{} { return truncateRunes(inner(r, seq).(string), st.length) }
+		}
+		return f
+	}
+
+	switch st.base {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint":
+		if st.base == "tinyint" && (st.length == 1 || has("is", "has", "active", "enabled", "flag")) {
+			return func(r *rand.Rand, _ int) interface{} { return int64(r.Intn(2)) }
+		}
+		lo, hi := intRange(st)
+		switch {
+		case has("age"):
+			lo, hi = 18, 90
+		case has("year"):
+			lo, hi = 1990, int64(time.Now().Year())
+		case has("qty", "quantity", "count", "stock", "amount", "total", "number", "num"):
+			lo, hi = 0, 1000
+		case has("rating", "stars", "score"):
+			lo, hi = 1, 5
+		case has("price", "cost"):
+			lo, hi = 1, 10000
+		case lo < 0 || hi > 1000000:
+			lo, hi = 1, 1000000
+		}
+		lo, hi = clampRange(lo, hi, st)
+		return func(r *rand.Rand, _ int) interface{} { return lo + r.Int63n(hi-lo+1) }
+	case "decimal", "numeric":
+		precision, scale := decimalSize(col.Type)
+		max := math.Pow10(precision-scale) - 1
+		if has("price", "cost", "amount", "total", "salary", "balance", "fee") && max > 10000 {
+			max = 10000
+		} else if max > 1000000 {
+			max = 1000000
+		}
+		min := 0.0
+		if !st.unsigned && allowsNegative(name) {
+			min = -max
+		}
+		return func(r *rand.Rand, _ int) interface{} {
+			return strconv.FormatFloat(min+r.Float64()*(max-min), 'f', scale, 64)
+		}
+	case "float", "double", "real":
+		switch {
+		case has("lat", "latitude"):
+			return func(r *rand.Rand, _ int) interface{} { return math.Round((r.Float64()*180-90)*1e6) / 1e6 }
+		case has("lng", "lon", "long", "longitude"):
+			return func(r *rand.Rand, _ int) interface{} { return math.Round((r.Float64()*360-180)*1e6) / 1e6 }
+		}
+		return func(r *rand.Rand, _ int) interface{} { return math.Round(r.Float64()*100000) / 100 }
+	case "bit", "bool", "boolean":
+		return func(r *rand.Rand, _ int) interface{} { return int64(r.Intn(2)) }
+	case "year":
+		return func(r *rand.Rand, _ int) interface{} { return 1990 + r.Intn(time.Now().Year()-1989) }
+	case "date":
+		return func(r *rand.Rand, _ int) interface{} { return fakeTime(r, name).Format("2006-01-02") }
+	case "datetime", "timestamp":
+		return func(r *rand.Rand, _ int) interface{} { return fakeTime(r, name).Format("2006-01-02 15:04:05") }
+	case "time":
+		return func(r *rand.Rand, _ int) interface{} {
+			return fmt.Sprintf("%02d:%02d:%02d", r.Intn(24), r.Intn(60), r.Intn(60))
+		}
+	case "enum":
+		return func(r *rand.Rand, _ int) interface{} { return pick(r, st.values) }
+	case "set":
+		return func(r *rand.Rand, _ int) interface{} {
+			var members []string
+			for _, v := range st.values {
+				if r.Intn(2) == 0 {
+					members = append(members, v)
+				}
+			}
+			return strings.Join(members, ",")
+		}
+	case "json":
+		return func(r *rand.Rand, seq int) interface{} {
+			b, _ := json.Marshal(map[string]interface{}{"id": seq, "tag": pick(r, fakeWords), "score": r.Intn(100)})
+			return string(b)
+		}
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		n := 16
+		if st.length > 0 && st.length < n {
+			n = st.length
+		}
+		return func(r *rand.Rand, _ int) interface{} {
+			b := make([]byte, n)
+			r.Read(b)
+			return b
+		}
+	}
+	return func(r *rand.Rand, _ int) interface{} { return words(r, 1) }
+}
+
+// allowsNegative reports whether negative values make sense for a column
+func allowsNegative(name string) bool {
+	return strings.Contains(name, "delta") || strings.Contains(name, "change") || strings.Contains(name, "diff")
+}
+
+func intRange(st sqlType) (int64, int64) {
+	bits := map[string]uint{"tinyint": 8, "smallint": 16, "mediumint": 24, "int": 32, "integer": 32, "bigint": 64}[st.base]
+	if st.unsigned {
+		if bits == 64 {
+			return 0, math.MaxInt64
+		}
+		return 0, int64(1)<<bits - 1
+	}
+	if bits == 64 {
+		return math.MinInt64, math.MaxInt64
+	}
+	return -(int64(1) << (bits - 1)), int64(1)<<(bits-1) - 1
+}
+
+func clampRange(lo, hi int64, st sqlType) (int64, int64) {
+	min, max := intRange(st)
+	if lo < min {
+		lo = min
+	}
+	if hi > max {
+		hi = max
+	}
+	if hi == math.MaxInt64 {
+		hi-- // keep hi-lo+1 from overflowing
+	}
+	return lo, hi
+}
+
+// decimalSize reads "decimal(10,2)"; MySQL's default is decimal(10,0)
+func decimalSize(t string) (int, int) {
+	precision, scale := 10, 0
+	if i := strings.IndexByte(t, '('); i >= 0 {
+		args := strings.TrimRight(strings.SplitN(t[i+1:], ")", 2)[0], " ")
+		parts := strings.Split(args, ",")
+		if n, err := strconv.Atoi(strings.TrimSpace(parts[0])); err == nil {
+			precision = n
+		}
+		if len(parts) > 1 {
+			if n, err := strconv.Atoi(strings.TrimSpace(parts[1])); err == nil {
+				scale = n
+			}
+		}
+	}
+	if precision-scale > 15 {
+		precision = scale + 15 // float64 precision
+	}
+	return precision, scale
+}
+
+// fakeTime returns a time in the past five years, or in the coming year
+// for names that suggest the future (due dates, expiry, ...)
+func fakeTime(r *rand.Rand, name string) time.Time {
+	now := time.Now()
+	if strings.Contains(name, "due") || strings.Contains(name, "expire") || strings.Contains(name, "end") || strings.Contains(name, "until") {
+		return now.Add(time.Duration(r.Int63n(int64(365 * 24 * time.Hour))))
+	}
+	if strings.Contains(name, "birth") || name == "dob" {
+		return now.AddDate(-18-r.Intn(60), 0, -r.Intn(365))
+	}
+	return now.Add(-time.Duration(r.Int63n(int64(5 * 365 * 24 * time.Hour))))
+}
+
+func pick(r *rand.Rand, list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+	return list[r.Intn(len(list))]
+}
+
+func words(r *rand.Rand, n int) string {
+	w := make([]string, n)
+	for i := range w {
+		w[i] = pick(r, fakeWords)
+	}
+	return strings.Join(w, " ")
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	_, size := utf8.DecodeRuneInString(s)
+	return strings.ToUpper(s[:size]) + s[size:]
+}
+
+// asciiLower keeps email local parts plain ASCII
+func asciiLower(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		case r == 'ü':
+			return 'u'
+		}
+		return -1
+	}, s)
+}
+
+const tokenAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+func randomToken(r *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = tokenAlphabet[r.Intn(len(tokenAlphabet))]
+	}
+	return string(b)
+}
+
+func fakeUUID(r *rand.Rand) string {
+	b := make([]byte, 16)
+	r.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
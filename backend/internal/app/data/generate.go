@@ -0,0 +1,307 @@
+package data
+
+import (
+	"backend/internal/domain"
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+const (
+	maxGenerateRows = 100000
+	// parentSample is how many existing parent keys child rows choose from
+	parentSample = 1000
+	// uniqueSample is how many existing unique values are loaded to avoid
+	// collisions; beyond that a duplicate fails the insert
+	uniqueSample = 100000
+	// uniqueAttempts is how often a row is regenerated to find an unused
+	// unique value before it is skipped
+	uniqueAttempts = 20
+	// nullRate is the share of NULLs in nullable, non-key columns
+	nullRate = 0.1
+)
+
+// Generate fills table with synthetic rows. Foreign keys point at existing
+// parent rows; empty parent tables are filled first, recursively. Each
+// table is inserted in its own transaction, so parents stay committed if a
+// child fails.
+func (s *dataService) Generate(ctx context.Context, table string, opts domain.GenerateOptions) (*domain.GenerateResult, error) {
+	if opts.Rows < 1 || opts.Rows > maxGenerateRows {
+		return nil, fmt.Errorf("rows must be between 1 and %d: %w", maxGenerateRows, domain.ErrInvalidInput)
+	}
+	if opts.ParentRows <= 0 {
+		opts.ParentRows = opts.Rows / 5
+		if opts.ParentRows < 1 {
+			opts.ParentRows = 1
+		}
+	}
+	if opts.ParentRows > maxGenerateRows {
+		opts.ParentRows = maxGenerateRows
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	g := &generator{
+		s:        s,
+		rand:     rand.New(rand.NewSource(seed)),
+		opts:     opts,
+		visiting: make(map[string]bool),
+		result:   &domain.GenerateResult{Tables: []domain.GeneratedTable{}},
+	}
+	if err := g.fill(ctx, table, opts.Rows); err != nil {
+		return g.result, err
+	}
+	return g.result, nil
+}
+
+type generator struct {
+	s        *dataService
+	rand     *rand.Rand
+	opts     domain.GenerateOptions
+	visiting map[string]bool // tables on the current parent chain
+	result   *domain.GenerateResult
+}
+
+// fkChoice is where the values of one foreign key come from
+type fkChoice struct {
+	fk       domain.ForeignKey
+	keys     [][]interface{} // sampled parent key tuples
+	nullable bool            // every column of the key accepts NULL
+}
+
+func (g *generator) fill(ctx context.Context, table string, rows int) error {
+	g.visiting[table] = true
+	defer delete(g.visiting, table)
+
+	cols, err := g.s.repo.GetColumns(ctx, table)
+	if err != nil {
+		return err
+	}
+	cons, err := g.s.repo.GetConstraints(ctx, table)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]domain.ColumnMeta, len(cols))
+	for _, c := range cols {
+		byName[c.Name] = c
+	}
+
+	choices := make([]fkChoice, 0, len(cons.ForeignKeys))
+	fkColumn := make(map[string]bool)
+	for _, fk := range cons.ForeignKeys {
+		choice := fkChoice{fk: fk, nullable: true}
+		for _, c := range fk.Columns {
+			fkColumn[c] = true
+			choice.nullable = choice.nullable && byName[c].Nullable
+		}
+		if choice.keys, err = g.s.repo.SampleRows(ctx, fk.RefTable, fk.RefColumns, parentSample); err != nil {
+			return err
+		}
+		if len(choice.keys) == 0 && !g.visiting[fk.RefTable] {
+			if err := g.fill(ctx, fk.RefTable, g.opts.ParentRows); err != nil {
+				return err
+			}
+			if choice.keys, err = g.s.repo.SampleRows(ctx, fk.RefTable, fk.RefColumns, parentSample); err != nil {
+				return err
+			}
+		}
+		if len(choice.keys) == 0 && !choice.nullable {
+			// a required self-reference or a cycle of required references
+			return fmt.Errorf("cannot generate rows for %s: foreign key %s needs existing rows in %s: %w",
+				table, fk.Name, fk.RefTable, domain.ErrInvalidInput)
+		}
+		choices = append(choices, choice)
+	}
+
+	// columns to write, in table order; foreign key columns are filled
+	// from the parent samples instead of a faker
+	var columns []string
+	fakers := make(map[string]valueFaker)
+	for _, c := range cols {
+		if c.IsAutoIncrement || c.IsGenerated {
+			continue
+		}
+		columns = append(columns, c.Name)
+		if !fkColumn[c.Name] {
+			fakers[c.Name] = fakerFor(c)
+		}
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("table %s has no writable columns: %w", table, domain.ErrInvalidInput)
+	}
+
+	uniques, err := g.loadUniques(ctx, table, cons.UniqueKeys, columns)
+	if err != nil {
+		return err
+	}
+	uniqueColumn := make(map[string]bool)
+	for _, u := range uniques {
+		for _, c := range u.columns {
+			uniqueColumn[c] = true
+		}
+	}
+
+	seq := 0
+	next := func() map[string]interface{} {
+		seq++
+		row := make(map[string]interface{}, len(columns))
+		for _, ch := range choices {
+			if len(ch.keys) == 0 || ch.nullable && g.rand.Float64() < nullRate {
+				for _, c := range ch.fk.Columns {
+					row[c] = nil
+				}
+				continue
+			}
+			key := ch.keys[g.rand.Intn(len(ch.keys))]
+			for i, c := range ch.fk.Columns {
+				row[c] = key[i]
+			}
+		}
+		for _, name := range columns {
+			fake, ok := fakers[name]
+			if !ok {
+				continue
+			}
+			col := byName[name]
+			if col.Nullable && !col.IsPK && !uniqueColumn[name] && g.rand.Float64() < nullRate {
+				row[name] = nil
+				continue
+			}
+			row[name] = fake(g.rand, seq)
+		}
+		return row
+	}
+
+	chunk := defaultChunkSize
+	if chunk*len(columns) > maxPlaceholders {
+		chunk = maxPlaceholders / len(columns)
+	}
+	skipped := 0
+	fill := func(insert func(columns []string, rows [][]interface{}) error) error {
+		batch := make([][]interface{}, 0, chunk)
+		for i := 0; i < rows; i++ {
+			var row map[string]interface{}
+			for attempt := 0; attempt < uniqueAttempts && row == nil; attempt++ {
+				candidate := next()
+				if uniques.claim(candidate) {
+					row = candidate
+				}
+			}
+			if row == nil {
+				skipped++
+				continue
+			}
+			values := make([]interface{}, len(columns))
+			for j, c := range columns {
+				values[j] = row[c]
+			}
+			batch = append(batch, values)
+			if len(batch) == chunk {
+				if err := insert(columns, batch); err != nil {
+					return err
+				}
+				batch = batch[:0]
+			}
+		}
+		if len(batch) > 0 {
+			return insert(columns, batch)
+		}
+		return nil
+	}
+
+	inserted, err := g.s.repo.InsertBatches(ctx, table, fill)
+	if err != nil {
+		return fmt.Errorf("generating rows for %s: %w", table, err)
+	}
+	g.result.Tables = append(g.result.Tables, domain.GeneratedTable{Table: table, Inserted: inserted, Skipped: skipped})
+	return nil
+}
+
+// uniqueSet tracks the tuples taken for each unique key of a table
+type uniqueSet []uniqueKey
+
+type uniqueKey struct {
+	columns []string
+	taken   map[string]bool
+}
+
+// loadUniques prepares the unique keys the generator writes to (keys on
+// auto-increment columns are unique by construction) with the values
+// already in the table
+func (g *generator) loadUniques(ctx context.Context, table string, keys [][]string, written []string) (uniqueSet, error) {
+	writes := make(map[string]bool, len(written))
+	for _, c := range written {
+		writes[c] = true
+	}
+	var set uniqueSet
+	for _, cols := range keys {
+		covered := true
+		for _, c := range cols {
+			covered = covered && writes[c]
+		}
+		if !covered {
+			continue
+		}
+		existing, err := g.s.repo.SampleRows(ctx, table, cols, uniqueSample)
+		if err != nil {
+			return nil, err
+		}
+		u := uniqueKey{columns: cols, taken: make(map[string]bool, len(existing))}
+		for _, tuple := range existing {
+			u.taken[tupleKey(tuple)] = true
+		}
+		set = append(set, u)
+	}
+	return set, nil
+}
+
+// claim records row's unique tuples, or reports false if one is taken.
+// Tuples containing NULL never collide, as in MySQL.
+func (s uniqueSet) claim(row map[string]interface{}) bool {
+	keys := make([]string, len(s))
+	checked := make([]bool, len(s))
+	for i, u := range s {
+		tuple := make([]interface{}, len(u.columns))
+		for j, c := range u.columns {
+			tuple[j] = row[c]
+			if row[c] == nil {
+				tuple = nil
+				break
+			}
+		}
+		if tuple == nil {
+			continue
+		}
+		keys[i], checked[i] = tupleKey(tuple), true
+		if u.taken[keys[i]] {
+			return false
+		}
+	}
+	for i, u := range s {
+		if checked[i] {
+			u.taken[keys[i]] = true
+		}
+	}
+	return true
+}
+
+// tupleKey normalizes scanned and generated values to comparable text.
+// MySQL's default collations compare case-insensitively, so keys are
+// lower-cased to stay on the safe side.
+func tupleKey(tuple []interface{}) string {
+	parts := make([]string, len(tuple))
+	for i, v := range tuple {
+		switch x := v.(type) {
+		case []byte:
+			parts[i] = string(x)
+		case time.Time:
+			parts[i] = x.Format("2006-01-02 15:04:05")
+		default:
+			parts[i] = fmt.Sprint(x)
+		}
+	}
+	return strings.ToLower(strings.Join(parts, "\x00"))
+}
@@ -0,0 +1,355 @@
+package data
+
+import (
+	"backend/internal/domain"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultChunkSize = 500
+	maxChunkSize     = 5000
+	// MySQL allows at most 65535 placeholders per statement
+	maxPlaceholders = 65535
+	maxRejected     = 1000
+)
+
+// RecordReader yields the records of an uploaded file as source-field to
+// value maps. Next returns io.EOF after the last record.
+type RecordReader interface {
+	Next() (map[string]interface{}, error)
+}
+
+// NewRecordReader returns a reader for csv, ndjson or xlsx input. Readers
+// that hold resources also implement io.Closer.
+func NewRecordReader(r io.Reader, opts domain.ImportOptions) (RecordReader, error) {
+	switch opts.Format {
+	case FormatCSV, "":
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		header, err := cr.Read()
+		if err != nil {
+			return nil, fmt.Errorf("reading csv header: %v: %w", err, domain.ErrInvalidInput)
+		}
+		if len(header) > 0 {
+			header[0] = strings.TrimPrefix(header[0], "\ufeff") // Excel BOM
+		}
+		return &csvRecordReader{r: cr, header: header}, nil
+	case FormatNDJSON, FormatJSON:
+		return &ndjsonRecordReader{r: bufio.NewReader(r)}, nil
+	case FormatXLSX:
+		return newXLSXRecordReader(r, opts.Sheet)
+	}
+	return nil, fmt.Errorf("unsupported import format %q (use csv, ndjson or xlsx): %w", opts.Format, domain.ErrInvalidInput)
+}
+
+type csvRecordReader struct {
+	r      *csv.Reader
+	header []string
+}
+
+func (c *csvRecordReader) Next() (map[string]interface{}, error) {
+	fields, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	rec := make(map[string]interface{}, len(c.header))
+	for i, h := range c.header {
+		if i < len(fields) {
+			rec[h] = fields[i]
+		}
+	}
+	return rec, nil
+}
+
+type ndjsonRecordReader struct {
+	r *bufio.Reader
+}
+
+func (n *ndjsonRecordReader) Next() (map[string]interface{}, error) {
+	for {
+		line, err := n.r.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.UseNumber()
+		var rec map[string]interface{}
+		if derr := dec.Decode(&rec); derr != nil {
+			return nil, &recordError{fmt.Sprintf("invalid JSON: %v", derr)}
+		}
+		return rec, nil
+	}
+}
+
+// recordError rejects a single record without aborting the import
+type recordError struct{ reason string }
+
+func (e *recordError) Error() string { return e.reason }
+
+// Import reads records from r, maps and coerces them to the table's
+// columns and inserts them in chunks inside one transaction. Rows that
+// cannot be converted are skipped and reported; a database error rolls
+// back the whole import.
+func (s *dataService) Import(ctx context.Context, table string, r io.Reader, opts domain.ImportOptions) (*domain.ImportResult, error) {
+	reader, err := NewRecordReader(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := reader.(io.Closer); ok {
+		defer c.Close()
+	}
+	return s.importRecords(ctx, table, reader, opts)
+}
+
+func (s *dataService) importRecords(ctx context.Context, table string, reader RecordReader, opts domain.ImportOptions) (*domain.ImportResult, error) {
+	cols, err := s.repo.GetColumns(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]domain.ColumnMeta, len(cols))
+	for _, c := range cols {
+		byName[strings.ToLower(c.Name)] = c
+	}
+
+	result := &domain.ImportResult{DryRun: opts.DryRun, Rejected: []domain.RejectedRow{}}
+	mapper := &fieldMapper{mapping: opts.Mapping, columns: byName}
+
+	chunk := opts.ChunkSize
+	if chunk <= 0 {
+		chunk = defaultChunkSize
+	}
+	if chunk > maxChunkSize {
+		chunk = maxChunkSize
+	}
+	if chunk*len(cols) > maxPlaceholders {
+		chunk = maxPlaceholders / len(cols)
+	}
+
+	reject := func(row int, reason string) {
+		result.RejectedCount++
+		if len(result.Rejected) < maxRejected {
+			result.Rejected = append(result.Rejected, domain.RejectedRow{Row: row, Reason: reason})
+		}
+	}
+
+	// The column list is resolved from the first record. Without a mapping,
+	// columns that first appear in a later record are added to it and the
+	// rows already batched get DEFAULT for them. Columns a record lacks are
+	// sent as DEFAULT, which is why unmapped required columns fail the
+	// import up front.
+	fill := func(insert func(columns []string, rows [][]interface{}) error) error {
+		var batch [][]interface{}
+		for rowNum := 1; ; rowNum++ {
+			rec, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			if rerr, ok := err.(*recordError); ok {
+				reject(rowNum, rerr.reason)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("row %d: %v: %w", rowNum, err, domain.ErrInvalidInput)
+			}
+
+			if mapper.targets == nil {
+				if err := mapper.resolve(rec); err != nil {
+					return err
+				}
+			} else if added, err := mapper.extend(rec); err != nil {
+				return err
+			} else {
+				for i := range batch {
+					for j := 0; j < added; j++ {
+						batch[i] = append(batch[i], domain.DefaultValue{})
+					}
+				}
+			}
+			values, reason := mapper.row(rec)
+			if reason != "" {
+				reject(rowNum, reason)
+				continue
+			}
+			batch = append(batch, values)
+			if len(batch) == chunk {
+				if err := insert(mapper.targetNames(), batch); err != nil {
+					return fmt.Errorf("inserting rows up to %d: %w", rowNum, err)
+				}
+				batch = nil
+			}
+		}
+		if len(batch) > 0 {
+			return insert(mapper.targetNames(), batch)
+		}
+		return nil
+	}
+
+	var inserted int64
+	if opts.DryRun {
+		err = fill(func(_ []string, rows [][]interface{}) error {
+			inserted += int64(len(rows))
+			return nil
+		})
+	} else {
+		inserted, err = s.repo.InsertBatches(ctx, table, fill)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Inserted = inserted
+	result.Columns = mapper.targetNames()
+	result.Ignored = mapper.ignored
+	return result, nil
+}
+
+// fieldMapper maps source fields to table columns. With an explicit
+// mapping only mapped fields are imported (an empty target skips a field);
+// otherwise fields are matched to columns by name, case-insensitively.
+type fieldMapper struct {
+	mapping map[string]string
+	columns map[string]domain.ColumnMeta
+
+	targets []domain.ColumnMeta // resolved on the first record, extended by later ones
+	sources []string            // source field for each target
+	ignored []string
+	known   map[string]bool // source fields already resolved or ignored
+}
+
+// resolve fixes the target columns from the fields of the first record (or
+// from the mapping). A mapping that cannot work fails the whole import.
+func (m *fieldMapper) resolve(rec map[string]interface{}) error {
+	m.known = make(map[string]bool)
+	fields := make([]string, 0, len(rec))
+	for f := range rec {
+		fields = append(fields, f)
+	}
+	if len(m.mapping) > 0 {
+		fields = fields[:0]
+		for f := range m.mapping {
+			fields = append(fields, f)
+		}
+	}
+	sort.Strings(fields)
+	for _, f := range fields {
+		if err := m.add(f); err != nil {
+			return err
+		}
+	}
+	if len(m.targets) == 0 {
+		return fmt.Errorf("no field matches a column of the table; send a mapping: %w", domain.ErrInvalidInput)
+	}
+
+	seen := make(map[string]bool, len(m.targets))
+	for _, col := range m.targets {
+		seen[col.Name] = true
+	}
+	var missing []string
+	for _, col := range m.columns {
+		if !seen[col.Name] && required(col) {
+			missing = append(missing, col.Name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("required columns are not mapped: %s: %w", strings.Join(missing, ", "), domain.ErrInvalidInput)
+	}
+	return nil
+}
+
+// extend resolves the fields of a later record that no earlier record had,
+// when there is no mapping, and returns how many target columns it added
+func (m *fieldMapper) extend(rec map[string]interface{}) (int, error) {
+	if len(m.mapping) > 0 {
+		return 0, nil
+	}
+	var fields []string
+	for f := range rec {
+		if !m.known[f] {
+			fields = append(fields, f)
+		}
+	}
+	sort.Strings(fields)
+	n := len(m.targets)
+	for _, f := range fields {
+		if err := m.add(f); err != nil {
+			return 0, err
+		}
+	}
+	return len(m.targets) - n, nil
+}
+
+// add resolves one source field to its target column, or ignores it
+func (m *fieldMapper) add(field string) error {
+	m.known[field] = true
+	target := field
+	if len(m.mapping) > 0 {
+		target = m.mapping[field]
+		if target == "" {
+			m.ignored = append(m.ignored, field)
+			return nil
+		}
+	}
+	col, ok := m.columns[strings.ToLower(target)]
+	if !ok {
+		if len(m.mapping) > 0 {
+			return fmt.Errorf("mapping target %q is not a column of the table: %w", target, domain.ErrInvalidInput)
+		}
+		m.ignored = append(m.ignored, field)
+		return nil
+	}
+	for _, t := range m.targets {
+		if t.Name == col.Name {
+			return fmt.Errorf("column %s is mapped more than once: %w", col.Name, domain.ErrInvalidInput)
+		}
+	}
+	m.targets = append(m.targets, col)
+	m.sources = append(m.sources, field)
+	return nil
+}
+
+// required reports whether a column needs a value in every row
+func required(col domain.ColumnMeta) bool {
+	return !col.Nullable && col.Default == nil && !col.IsAutoIncrement
+}
+
+// row converts one record; a non-empty reason means it is rejected.
+// Fields the record lacks get the column default.
+func (m *fieldMapper) row(rec map[string]interface{}) ([]interface{}, string) {
+	values := make([]interface{}, len(m.targets))
+	for i, col := range m.targets {
+		v, ok := rec[m.sources[i]]
+		if !ok {
+			if required(col) {
+				return nil, col.Name + " is required"
+			}
+			values[i] = domain.DefaultValue{}
+			continue
+		}
+		v, err := coerceValue(col, v)
+		if err != nil {
+			return nil, err.Error()
+		}
+		values[i] = v
+	}
+	return values, ""
+}
+
+func (m *fieldMapper) targetNames() []string {
+	names := make([]string, len(m.targets))
+	for i, c := range m.targets {
+		names[i] = c.Name
+	}
+	return names
+}
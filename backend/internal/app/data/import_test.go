@@ -0,0 +1,106 @@
+package data
+
+import (
+	"backend/internal/domain"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// importRepo records the batches an import inserts
+type importRepo struct {
+	domain.SchemaRepository
+	columns []domain.ColumnMeta
+	batches []importBatch
+}
+
+type importBatch struct {
+	columns []string
+	rows    [][]interface{}
+}
+
+func (r *importRepo) GetColumns(ctx context.Context, table string) ([]domain.ColumnMeta, error) {
+	return r.columns, nil
+}
+
+func (r *importRepo) InsertBatches(ctx context.Context, table string, fill func(insert func(columns []string, rows [][]interface{}) error) error) (int64, error) {
+	var n int64
+	err := fill(func(columns []string, rows [][]interface{}) error {
+		r.batches = append(r.batches, importBatch{columns, rows})
+		n += int64(len(rows))
+		return nil
+	})
+	return n, err
+}
+
+func TestImportHeterogeneousNDJSON(t *testing.T) {
+	def := "x"
+	repo := &importRepo{columns: []domain.ColumnMeta{
+		{Name: "id", Type: "int", IsPK: true, IsAutoIncrement: true},
+		{Name: "name", Type: "varchar(20)"},
+		{Name: "note", Type: "varchar(20)", Nullable: true},
+		{Name: "tag", Type: "varchar(20)", Default: &def},
+	}}
+	input := strings.Join([]string{
+		`{"name": "a"}`,
+		`{"name": "b", "note": "n", "extra": 1}`,
+		`{"note": "m"}`,
+		`{"name": "c", "tag": "t", "Extra": 2, "note": null}`,
+	}, "\n")
+	svc := &dataService{repo: repo}
+	result, err := svc.Import(context.Background(), "t", strings.NewReader(input), domain.ImportOptions{Format: FormatNDJSON, ChunkSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Inserted != 3 {
+		t.Errorf("Inserted = %d, want 3", result.Inserted)
+	}
+	if want := []string{"name", "note", "tag"}; !reflect.DeepEqual(result.Columns, want) {
+		t.Errorf("Columns = %q, want %q", result.Columns, want)
+	}
+	if want := []string{"extra", "Extra"}; !reflect.DeepEqual(result.Ignored, want) {
+		t.Errorf("Ignored = %q, want %q", result.Ignored, want)
+	}
+	if len(result.Rejected) != 1 || result.Rejected[0].Row != 3 || result.Rejected[0].Reason != "name is required" {
+		t.Errorf("Rejected = %+v, want row 3: name is required", result.Rejected)
+	}
+
+	def0 := domain.DefaultValue{}
+	want := []importBatch{{
+		columns: []string{"name", "note", "tag"},
+		rows: [][]interface{}{
+			{"a", def0, def0},
+			{"b", "n", def0},
+			{"c", nil, "t"},
+		},
+	}}
+	if !reflect.DeepEqual(repo.batches, want) {
+		t.Errorf("batches = %#v, want %#v", repo.batches, want)
+	}
+}
+
+func TestImportMappedFieldMissing(t *testing.T) {
+	def := "x"
+	repo := &importRepo{columns: []domain.ColumnMeta{
+		{Name: "name", Type: "varchar(20)"},
+		{Name: "tag", Type: "varchar(20)", Default: &def},
+	}}
+	input := "{\"n\": \"a\", \"t\": \"b\"}\n{\"n\": \"c\"}\n{\"t\": \"d\"}\n"
+	svc := &dataService{repo: repo}
+	result, err := svc.Import(context.Background(), "t", strings.NewReader(input), domain.ImportOptions{
+		Format:  FormatNDJSON,
+		Mapping: map[string]string{"n": "name", "t": "tag"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Inserted != 2 || result.RejectedCount != 1 {
+		t.Errorf("Inserted = %d, RejectedCount = %d, want 2 and 1", result.Inserted, result.RejectedCount)
+	}
+	want := [][]interface{}{{"a", "b"}, {"c", domain.DefaultValue{}}}
+	if len(repo.batches) != 1 || !reflect.DeepEqual(repo.batches[0].rows, want) {
+		t.Errorf("batches = %#v, want rows %#v", repo.batches, want)
+	}
+}
@@ -0,0 +1,150 @@
+package data
+
+import (
+	"backend/internal/domain"
+	"context"
+	"fmt"
+	"sort"
+)
+
+const (
+	defaultRelatedLimit = 20
+	maxRelatedLimit     = 200
+)
+
+// GetRelated follows the foreign keys of one row in both directions: the
+// parent row behind each outgoing key and the child rows of each table
+// whose keys reference it.
+func (s *dataService) GetRelated(ctx context.Context, q domain.RelatedQuery) (*domain.RelatedRows, error) {
+	if q.Limit <= 0 {
+		q.Limit = defaultRelatedLimit
+	}
+	if q.Limit > maxRelatedLimit {
+		q.Limit = maxRelatedLimit
+	}
+	cols, err := s.repo.GetColumns(ctx, q.Table)
+	if err != nil {
+		return nil, err
+	}
+	key, err := relatedKey(q, cols)
+	if err != nil {
+		return nil, err
+	}
+
+	keyCols, keyValues := splitKey(key)
+	data, err := s.findRows(ctx, q.Table, keyCols, keyValues, 1, domain.CountNone)
+	if err != nil {
+		return nil, err
+	}
+	if len(data.Rows) == 0 {
+		return nil, fmt.Errorf("row not found in %s: %w", q.Table, domain.ErrNotFound)
+	}
+	row := data.Rows[0]
+
+	result := &domain.RelatedRows{
+		Table:    q.Table,
+		Key:      key,
+		Row:      newRowEncoder(q.Table, data.ColumnMeta, q.MaxValueSize).encode(row),
+		Parents:  []domain.RelatedParent{},
+		Children: []domain.RelatedChildren{},
+	}
+
+	cons, err := s.repo.GetConstraints(ctx, q.Table)
+	if err != nil {
+		return nil, err
+	}
+	for _, fk := range cons.ForeignKeys {
+		parent := domain.RelatedParent{Relation: fk}
+		values, ok := keyValuesOf(row, fk.Columns)
+		if ok {
+			pd, err := s.findRows(ctx, fk.RefTable, fk.RefColumns, values, 1, domain.CountNone)
+			if err != nil {
+				return nil, err
+			}
+			if len(pd.Rows) > 0 {
+				parent.Row = newRowEncoder(fk.RefTable, pd.ColumnMeta, q.MaxValueSize).encode(pd.Rows[0])
+			}
+		}
+		result.Parents = append(result.Parents, parent)
+	}
+
+	refs, err := s.repo.GetReferencingKeys(ctx, q.Table)
+	if err != nil {
+		return nil, err
+	}
+	for _, fk := range refs {
+		children := domain.RelatedChildren{Relation: fk, Rows: []map[string]interface{}{}}
+		values, ok := keyValuesOf(row, fk.RefColumns)
+		if ok {
+			cd, err := s.findRows(ctx, fk.Table, fk.Columns, values, q.Limit, domain.CountExact)
+			if err != nil {
+				return nil, err
+			}
+			enc := newRowEncoder(fk.Table, cd.ColumnMeta, q.MaxValueSize)
+			for _, r := range cd.Rows {
+				children.Rows = append(children.Rows, enc.encode(r))
+			}
+			children.Total = cd.Total
+			children.HasMore = cd.Total > int64(len(cd.Rows))
+		}
+		result.Children = append(result.Children, children)
+	}
+	return result, nil
+}
+
+// relatedKey resolves the row key from a single PK value or a key object
+func relatedKey(q domain.RelatedQuery, cols []domain.ColumnMeta) (map[string]interface{}, error) {
+	if len(q.Key) > 0 {
+		return q.Key, nil
+	}
+	var pk []string
+	for _, c := range cols {
+		if c.IsPK {
+			pk = append(pk, c.Name)
+		}
+	}
+	switch {
+	case q.PK == "":
+		return nil, fmt.Errorf("pk or key is required: %w", domain.ErrInvalidInput)
+	case len(pk) == 0:
+		return nil, fmt.Errorf("table %s has no primary key; identify the row with key: %w", q.Table, domain.ErrInvalidInput)
+	case len(pk) > 1:
+		return nil, fmt.Errorf("table %s has a composite primary key (%v); use key: %w", q.Table, pk, domain.ErrInvalidInput)
+	}
+	return map[string]interface{}{pk[0]: q.PK}, nil
+}
+
+// findRows reads the raw rows whose columns equal values
+func (s *dataService) findRows(ctx context.Context, table string, columns []string, values []interface{}, limit int, count string) (*domain.TableData, error) {
+	filter := &domain.FilterGroup{}
+	for i, c := range columns {
+		filter.Conditions = append(filter.Conditions, domain.FilterCondition{Column: c, Operator: domain.OpEq, Value: values[i]})
+	}
+	return s.repo.GetTableData(ctx, table, domain.DataQuery{Limit: limit, Filter: filter, Count: count})
+}
+
+func splitKey(key map[string]interface{}) ([]string, []interface{}) {
+	cols := make([]string, 0, len(key))
+	for c := range key {
+		cols = append(cols, c)
+	}
+	sort.Strings(cols)
+	values := make([]interface{}, len(cols))
+	for i, c := range cols {
+		values[i] = key[c]
+	}
+	return cols, values
+}
+
+// keyValuesOf picks the key columns of row; a NULL part means the key
+// does not reference anything
+func keyValuesOf(row map[string]interface{}, columns []string) ([]interface{}, bool) {
+	values := make([]interface{}, len(columns))
+	for i, c := range columns {
+		if row[c] == nil {
+			return nil, false
+		}
+		values[i] = row[c]
+	}
+	return values, true
+}
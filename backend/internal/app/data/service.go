@@ -3,6 +3,7 @@ package data
 import (
 	"backend/internal/domain"
 	"context"
+	"fmt"
 )
 
 type dataService struct {
@@ -13,10 +14,117 @@ func NewDataService(repo domain.SchemaRepository) domain.DataService {
 	return &dataService{repo: repo}
 }
 
-func (s *dataService) GetData(ctx context.Context, table string, limit, offset int) (*domain.TableData, error) {
-	return s.repo.GetTableData(ctx, table, limit, offset)
-}
-
 func (s *dataService) Insert(ctx context.Context, table string, data map[string]interface{}) error {
 	return s.repo.InsertData(ctx, table, data)
 }
+
+func (s *dataService) Update(ctx context.Context, table string, change domain.RowChange) (int64, error) {
+	cond, err := s.condition(ctx, table, change)
+	if err != nil {
+		return 0, err
+	}
+	return s.repo.UpdateData(ctx, table, cond, change.Values)
+}
+
+func (s *dataService) Delete(ctx context.Context, table string, change domain.RowChange) (int64, error) {
+	cond, err := s.condition(ctx, table, change)
+	if err != nil {
+		return 0, err
+	}
+	return s.repo.DeleteData(ctx, table, cond)
+}
+
+func (s *dataService) condition(ctx context.Context, table string, change domain.RowChange) (map[string]interface{}, error) {
+	cols, err := s.repo.GetColumns(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	return rowCondition(table, cols, change)
+}
+
+// rowCondition picks the columns that identify the row: the primary key
+// when the table has one, otherwise every column of the original row.
+func rowCondition(table string, cols []domain.ColumnMeta, change domain.RowChange) (map[string]interface{}, error) {
+	var pk []string
+	for _, c := range cols {
+		if c.IsPK {
+			pk = append(pk, c.Name)
+		}
+	}
+
+	cond := make(map[string]interface{})
+	if len(pk) > 0 {
+		for _, name := range pk {
+			v, ok := change.Key[name]
+			if !ok {
+				v, ok = change.Row[name]
+			}
+			if !ok {
+				return nil, fmt.Errorf("missing primary key column %q for %s: %w", name, table, domain.ErrInvalidInput)
+			}
+			cond[name] = v
+		}
+		return cond, nil
+	}
+
+	// No primary key: only a complete row is specific enough.
+	for _, c := range cols {
+		v, ok := change.Row[c.Name]
+		if !ok {
+			return nil, fmt.Errorf("table %s has no primary key; send the full original row (missing %q): %w", table, c.Name, domain.ErrInvalidInput)
+		}
+		cond[c.Name] = v
+	}
+	return cond, nil
+}
+
+const maxChangesetSize = 1000
+
+// ApplyChangeset resolves which row each operation targets and hands the
+// whole batch to the repository to run in one transaction.
+func (s *dataService) ApplyChangeset(ctx context.Context, cs domain.Changeset) (*domain.ChangesetResult, error) {
+	if len(cs.Operations) == 0 {
+		return nil, fmt.Errorf("changeset is empty: %w", domain.ErrInvalidInput)
+	}
+	if len(cs.Operations) > maxChangesetSize {
+		return nil, fmt.Errorf("changeset has more than %d operations: %w", maxChangesetSize, domain.ErrInvalidInput)
+	}
+
+	columns := make(map[string][]domain.ColumnMeta)
+	writes := make([]domain.RowWrite, len(cs.Operations))
+	for i, op := range cs.Operations {
+		if op.Table == "" {
+			return nil, fmt.Errorf("operation %d: table is required: %w", i, domain.ErrInvalidInput)
+		}
+		w := domain.RowWrite{Type: op.Type, Table: op.Table, Values: op.Values}
+
+		switch op.Type {
+		case domain.ChangeInsert:
+			if len(op.Values) == 0 {
+				return nil, fmt.Errorf("operation %d: insert needs values: %w", i, domain.ErrInvalidInput)
+			}
+		case domain.ChangeUpdate, domain.ChangeDelete:
+			cols, ok := columns[op.Table]
+			if !ok {
+				var err error
+				if cols, err = s.repo.GetColumns(ctx, op.Table); err != nil {
+					return nil, fmt.Errorf("operation %d: %w", i, err)
+				}
+				columns[op.Table] = cols
+			}
+			cond, err := rowCondition(op.Table, cols, op.RowChange)
+			if err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+			w.Condition = cond
+			if op.Type == domain.ChangeDelete {
+				w.Values = nil
+			}
+		default:
+			return nil, fmt.Errorf("operation %d: type must be insert, update or delete: %w", i, domain.ErrInvalidInput)
+		}
+		writes[i] = w
+	}
+
+	return s.repo.ApplyWrites(ctx, writes)
+}
@@ -0,0 +1,201 @@
+package data
+
+import (
+	"backend/internal/domain"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// valuePath is the route serving complete values (see routes.go)
+const valuePath = "/api/data/value"
+
+const (
+	// maxSafeInteger is the largest integer a float64 (a JavaScript
+	// number) holds exactly
+	maxSafeInteger = 1<<53 - 1
+	// hexLimit is the largest binary value shown as hex rather than base64
+	hexLimit = 64
+)
+
+func (s *dataService) GetData(ctx context.Context, table string, q domain.DataQuery) (*domain.TableData, error) {
+	data, err := s.repo.GetTableData(ctx, table, q)
+	if err != nil {
+		return nil, err
+	}
+	enc := newRowEncoder(table, data.ColumnMeta, q.MaxValueSize)
+	for i, row := range data.Rows {
+		data.Rows[i] = enc.encode(row)
+	}
+	return data, nil
+}
+
+// GetValue fetches one complete value by primary key
+func (s *dataService) GetValue(ctx context.Context, table, column string, key map[string]interface{}) (*domain.CellValue, error) {
+	cols, err := s.repo.GetColumns(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	var col *domain.ColumnMeta
+	for i := range cols {
+		if cols[i].Name == column {
+			col = &cols[i]
+		}
+	}
+	if col == nil {
+		return nil, fmt.Errorf("unknown column %q: %w", column, domain.ErrInvalidInput)
+	}
+	v, err := s.repo.GetValue(ctx, table, column, key)
+	if err != nil {
+		return nil, err
+	}
+	cell := &domain.CellValue{Column: *col}
+	switch x := v.(type) {
+	case nil:
+	case []byte:
+		cell.Binary, cell.Data = true, x
+	default:
+		cell.Data = []byte(fmt.Sprint(encodeValue(parseSQLType(col.Type), x)))
+	}
+	return cell, nil
+}
+
+// rowEncoder serializes raw driver values by column type
+type rowEncoder struct {
+	table   string
+	types   map[string]sqlType
+	pk      []string
+	maxSize int
+}
+
+func newRowEncoder(table string, cols []domain.ColumnMeta, maxSize int) *rowEncoder {
+	e := &rowEncoder{table: table, types: make(map[string]sqlType, len(cols)), maxSize: maxSize}
+	for _, c := range cols {
+		e.types[c.Name] = parseSQLType(c.Type)
+		if c.IsPK {
+			e.pk = append(e.pk, c.Name)
+		}
+	}
+	return e
+}
+
+func (e *rowEncoder) encode(row map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(row))
+	for name, v := range row {
+		st := e.types[name]
+		switch x := v.(type) {
+		case []byte:
+			if st.base == "bit" {
+				out[name] = bitValue(x)
+				continue
+			}
+			out[name] = e.binary(row, name, x)
+		case string:
+			if e.maxSize > 0 && len(x) > e.maxSize && utf8.RuneCountInString(x) > e.maxSize {
+				out[name] = domain.TruncatedValue{
+					Value:     truncateRunes(x, e.maxSize),
+					Size:      utf8.RuneCountInString(x),
+					Truncated: true,
+					URL:       e.valueURL(row, name),
+				}
+				continue
+			}
+			out[name] = x
+		default:
+			out[name] = encodeValue(st, v)
+		}
+	}
+	return out
+}
+
+func (e *rowEncoder) binary(row map[string]interface{}, name string, b []byte) domain.BinaryValue {
+	data := b
+	bv := domain.BinaryValue{Size: len(b)}
+	if e.maxSize > 0 && len(b) > e.maxSize {
+		data = b[:e.maxSize]
+		bv.Truncated = true
+		bv.URL = e.valueURL(row, name)
+	}
+	if len(b) <= hexLimit {
+		bv.Encoding, bv.Data = "hex", hex.EncodeToString(data)
+	} else {
+		bv.Encoding, bv.Data = "base64", base64.StdEncoding.EncodeToString(data)
+	}
+	return bv
+}
+
+// valueURL links to the complete value; it is empty for tables without a
+// primary key or with a binary one, whose rows cannot be addressed
+func (e *rowEncoder) valueURL(row map[string]interface{}, column string) string {
+	if len(e.pk) == 0 {
+		return ""
+	}
+	key := make(map[string]interface{}, len(e.pk))
+	for _, c := range e.pk {
+		if _, ok := row[c].([]byte); ok {
+			return ""
+		}
+		key[c] = encodeValue(e.types[c], row[c])
+	}
+	k, err := json.Marshal(key)
+	if err != nil {
+		return ""
+	}
+	q := url.Values{"table": {e.table}, "column": {column}, "key": {string(k)}}
+	return valuePath + "?" + q.Encode()
+}
+
+// EncodeValue serializes a raw driver value for JSON clients the same way
+// the data browser does (binary values are never truncated here). sqlType
+// is a column type or a driver type name such as "DECIMAL".
+func EncodeValue(sqlType string, v interface{}) interface{} {
+	st := parseSQLType(sqlType)
+	if b, ok := v.([]byte); ok {
+		if st.base == "bit" {
+			return bitValue(b)
+		}
+		return (&rowEncoder{}).binary(nil, "", b)
+	}
+	return encodeValue(st, v)
+}
+
+// encodeValue converts scalar driver values to JSON-friendly ones
+func encodeValue(st sqlType, v interface{}) interface{} {
+	switch x := v.(type) {
+	case time.Time:
+		if st.base == "date" {
+			return x.Format("2006-01-02")
+		}
+		return x.Format("2006-01-02T15:04:05.999999")
+	case int64:
+		if x > maxSafeInteger || x < -maxSafeInteger {
+			return strconv.FormatInt(x, 10)
+		}
+		return x
+	case uint64:
+		if x > maxSafeInteger {
+			return strconv.FormatUint(x, 10)
+		}
+		return x
+	case float32:
+		// print with float32 precision so 0.1 does not become 0.10000000149
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(x), 'g', -1, 32), 64)
+		return f
+	}
+	return v
+}
+
+// bitValue reads a BIT(n) value, which the driver returns big-endian
+func bitValue(b []byte) uint64 {
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n
+}
@@ -0,0 +1,283 @@
+package data
+
+import (
+	"backend/internal/domain"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// FormatXLSX is an Excel workbook; exports write one sheet per table
+const FormatXLSX = "xlsx"
+
+const maxSheetName = 31
+
+// xlsxRowWriter streams rows into a sheet of an in-memory workbook. The
+// workbook is only written to w by End (or writeTo for several sheets),
+// since the zip container cannot be produced incrementally.
+type xlsxRowWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	sw     *excelize.StreamWriter
+	sheet  string // name for the next sheet started by Begin
+	sheets int
+	row    int
+	multi  bool // End leaves the workbook open for more sheets
+}
+
+func newXLSXRowWriter(w io.Writer) *xlsxRowWriter {
+	return &xlsxRowWriter{w: w, file: excelize.NewFile(), sheet: "Sheet1"}
+}
+
+func (x *xlsxRowWriter) Begin(columns []string) error {
+	name := x.sheetName(x.sheet)
+	if x.sheets == 0 {
+		if err := x.file.SetSheetName("Sheet1", name); err != nil {
+			return err
+		}
+	} else if _, err := x.file.NewSheet(name); err != nil {
+		return err
+	}
+	x.sheets++
+
+	sw, err := x.file.NewStreamWriter(name)
+	if err != nil {
+		return err
+	}
+	x.sw, x.row = sw, 1
+	header := make([]interface{}, len(columns))
+	for i, c := range columns {
+		header[i] = c
+	}
+	return x.writeRow(header)
+}
+
+func (x *xlsxRowWriter) Row(values []interface{}) error {
+	cells := make([]interface{}, len(values))
+	for i, v := range values {
+		cells[i] = xlsxValue(v)
+	}
+	return x.writeRow(cells)
+}
+
+func (x *xlsxRowWriter) writeRow(cells []interface{}) error {
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	x.row++
+	return x.sw.SetRow(cell, cells)
+}
+
+func (x *xlsxRowWriter) End() error {
+	if err := x.sw.Flush(); err != nil {
+		return err
+	}
+	if x.multi {
+		return nil
+	}
+	return x.writeTo()
+}
+
+func (x *xlsxRowWriter) writeTo() error {
+	defer x.file.Close()
+	return x.file.Write(x.w)
+}
+
+func (x *xlsxRowWriter) ContentType() string {
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+func (x *xlsxRowWriter) Extension() string { return "xlsx" }
+
+// sheetName makes name a valid, unused sheet name: at most 31 characters
+// and none of : \ / ? * [ ]
+func (x *xlsxRowWriter) sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`:\/?*[]`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		name = "Sheet"
+	}
+	base := truncateRunes(name, maxSheetName)
+	candidate := base
+	for n := 2; x.sheets > 0 && x.hasSheet(candidate); n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		candidate = truncateRunes(name, maxSheetName-len(suffix)) + suffix
+	}
+	return candidate
+}
+
+func (x *xlsxRowWriter) hasSheet(name string) bool {
+	for _, s := range x.file.GetSheetList() {
+		if strings.EqualFold(s, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+// xlsxValue keeps numbers and times as native cell values. The text
+// protocol returns numbers as bytes, so a value is written as a number
+// only when that round-trips exactly (leading zeros and long digit
+// strings such as phone numbers stay text).
+func xlsxValue(v interface{}) interface{} {
+	b, ok := v.([]byte)
+	if !ok {
+		return v
+	}
+	s := string(b)
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil && strconv.FormatInt(n, 10) == s && len(s) <= 15 {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strconv.FormatFloat(f, 'f', -1, 64) == s && len(s) <= 16 {
+		return f
+	}
+	return s
+}
+
+// ExportWorkbook streams several tables into one workbook, one sheet per
+// table in the given order. Filter and sort only apply to a single table.
+func (s *dataService) ExportWorkbook(ctx context.Context, tables []string, q domain.DataQuery) (*domain.Export, error) {
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("no tables to export: %w", domain.ErrInvalidInput)
+	}
+	if len(tables) > 1 && (q.Filter != nil || len(q.Sort) > 0) {
+		return nil, fmt.Errorf("filter and sort can only be used when exporting one table: %w", domain.ErrInvalidInput)
+	}
+	// check every table up front; after streaming starts errors can no
+	// longer be reported to the client
+	for _, t := range tables {
+		if _, err := s.repo.GetColumns(ctx, t); err != nil {
+			return nil, err
+		}
+	}
+
+	name := tables[0]
+	if len(tables) > 1 {
+		name = "export"
+	}
+	probe := newXLSXRowWriter(io.Discard)
+	return &domain.Export{
+		ContentType: probe.ContentType(),
+		Filename:    name + "." + probe.Extension(),
+		Write: func(w io.Writer) error {
+			rw := newXLSXRowWriter(w)
+			rw.multi = true
+			for _, t := range tables {
+				stream, err := s.repo.StreamTable(ctx, t, q)
+				if err != nil {
+					return err
+				}
+				rw.sheet = t
+				err = writeStream(stream, rw, io.Discard)
+				stream.Close()
+				if err != nil {
+					return err
+				}
+			}
+			return rw.writeTo()
+		},
+	}, nil
+}
+
+// xlsxRecordReader reads a worksheet whose first row is the header. Cells
+// are read raw (unformatted), so dates arrive as spreadsheet serial
+// numbers and are converted by the column coercion.
+type xlsxRecordReader struct {
+	file   *excelize.File
+	rows   *excelize.Rows
+	header []string
+}
+
+// newXLSXRecordReader opens sheet (the first sheet when empty)
+func newXLSXRecordReader(r io.Reader, sheet string) (*xlsxRecordReader, error) {
+	f, err := excelize.OpenReader(r, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, fmt.Errorf("reading workbook: %v: %w", err, domain.ErrInvalidInput)
+	}
+	sheets := f.GetSheetList()
+	if sheet == "" && len(sheets) > 0 {
+		sheet = sheets[0]
+	}
+	found := false
+	for _, s := range sheets {
+		found = found || s == sheet
+	}
+	if !found {
+		f.Close()
+		return nil, fmt.Errorf("sheet %q not found (sheets: %s): %w", sheet, strings.Join(sheets, ", "), domain.ErrInvalidInput)
+	}
+	rows, err := f.Rows(sheet)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	x := &xlsxRecordReader{file: f, rows: rows}
+	if !rows.Next() {
+		x.Close()
+		return nil, fmt.Errorf("sheet %q is empty: %w", sheet, domain.ErrInvalidInput)
+	}
+	if x.header, err = rows.Columns(); err != nil {
+		x.Close()
+		return nil, err
+	}
+	return x, nil
+}
+
+func (x *xlsxRecordReader) Next() (map[string]interface{}, error) {
+	for x.rows.Next() {
+		cells, err := x.rows.Columns()
+		if err != nil {
+			return nil, err
+		}
+		if isBlankRow(cells) {
+			continue
+		}
+		rec := make(map[string]interface{}, len(x.header))
+		for i, h := range x.header {
+			if h == "" {
+				continue
+			}
+			if i < len(cells) {
+				rec[h] = cells[i]
+			} else {
+				rec[h] = ""
+			}
+		}
+		return rec, nil
+	}
+	if err := x.rows.Error(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (x *xlsxRecordReader) Close() error {
+	x.rows.Close()
+	return x.file.Close()
+}
+
+func isBlankRow(cells []string) bool {
+	for _, c := range cells {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
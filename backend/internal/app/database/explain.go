@@ -0,0 +1,265 @@
+package database
+
+import (
+	"backend/internal/domain"
+	"backend/internal/sqlutil"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Explain runs EXPLAIN FORMAT=JSON for one statement and normalizes the
+// plan. With Analyze the statement is also executed, through EXPLAIN
+// ANALYZE on MySQL 8.0.18+ or ANALYZE FORMAT=JSON on MariaDB; older
+// servers get the estimated plan and a warning.
+func (s *databaseService) Explain(ctx context.Context, req domain.ExplainRequest) (*domain.ExplainResult, error) {
+	stmts, err := SplitStatements(req.Query)
+	if err != nil {
+		return nil, err
+	}
+	if len(stmts) != 1 {
+		return nil, fmt.Errorf("explain needs exactly one statement, got %d: %w", len(stmts), domain.ErrInvalidInput)
+	}
+	stmt := stmts[0].Text
+	keyword := strings.ToUpper(sqlutil.FirstKeyword(stmt))
+	switch keyword {
+	case "SELECT", "WITH", "TABLE", "INSERT", "REPLACE", "UPDATE", "DELETE":
+	default:
+		return nil, fmt.Errorf("%s statements cannot be explained: %w", keyword, domain.ErrInvalidInput)
+	}
+	if req.Analyze && StatementType(stmt) != domain.StmtSelect {
+		// ANALYZE executes the statement
+		return nil, fmt.Errorf("analyze is only allowed for SELECT statements: %w", domain.ErrInvalidInput)
+	}
+
+	if s.queryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.queryTimeout)
+		defer cancel()
+	}
+	session, err := s.repo.OpenSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	if req.Database != "" {
+		if err := session.Use(ctx, req.Database); err != nil {
+			return nil, fmt.Errorf("selecting database %s: %v: %w", req.Database, err, domain.ErrInvalidInput)
+		}
+	}
+
+	raw, err := scalar(ctx, session, "EXPLAIN FORMAT=JSON "+stmt)
+	if err != nil {
+		return nil, fmt.Errorf("explaining statement: %v: %w", err, domain.ErrInvalidInput)
+	}
+	var doc map[string]interface{}
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("reading plan: %v", err)
+	}
+	result := &domain.ExplainResult{
+		Plan:     planFromJSON(doc),
+		Tables:   []domain.TableSchema{},
+		Warnings: []string{},
+		Raw:      json.RawMessage(raw),
+	}
+
+	// the rewritten query in the note of EXPLAIN names the table behind
+	// every alias; it has to be read before the next statement
+	aliases := map[string]tableRef{}
+	if note, err := explainNote(ctx, session); err == nil {
+		aliases = tableAliases(note)
+	}
+
+	if req.Analyze {
+		analyzed, text, err := s.analyze(ctx, session, stmt)
+		if err != nil {
+			return nil, err
+		}
+		if analyzed == nil {
+			result.Warnings = append(result.Warnings, "this server does not support EXPLAIN ANALYZE")
+		}
+		result.Analyzed, result.AnalyzeText = analyzed, text
+	}
+
+	database := req.Database
+	if database == "" {
+		if database, err = scalar(ctx, session, "SELECT DATABASE()"); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.annotate(ctx, result, database, aliases); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// analyze executes stmt with the server's ANALYZE flavour. A nil plan
+// means the server has none.
+func (s *databaseService) analyze(ctx context.Context, session domain.SQLSession, stmt string) (*domain.PlanNode, string, error) {
+	version, err := scalar(ctx, session, "SELECT VERSION()")
+	if err != nil {
+		return nil, "", err
+	}
+	if strings.Contains(strings.ToLower(version), "mariadb") {
+		raw, err := scalar(ctx, session, "ANALYZE FORMAT=JSON "+stmt)
+		if err != nil {
+			return nil, "", fmt.Errorf("analyzing statement: %v: %w", err, domain.ErrInvalidInput)
+		}
+		var doc map[string]interface{}
+		dec := json.NewDecoder(strings.NewReader(raw))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return nil, "", fmt.Errorf("reading analyzed plan: %v", err)
+		}
+		plan := planFromJSON(doc)
+		return &plan, raw, nil
+	}
+	if !versionAtLeast(version, 8, 0, 18) {
+		return nil, "", nil
+	}
+	text, err := scalar(ctx, session, "EXPLAIN ANALYZE "+stmt)
+	if err != nil {
+		return nil, "", fmt.Errorf("analyzing statement: %v: %w", err, domain.ErrInvalidInput)
+	}
+	return planFromTree(text), text, nil
+}
+
+// annotate resolves aliases to tables, attaches the schema of the tables
+// in database that the plans read, and collects the node warnings
+func (s *databaseService) annotate(ctx context.Context, result *domain.ExplainResult, database string, aliases map[string]tableRef) error {
+	used := map[string]bool{}
+	var walk func(n *domain.PlanNode)
+	walk = func(n *domain.PlanNode) {
+		if n.Alias != "" && !strings.HasPrefix(n.Alias, "<") {
+			n.Table = n.Alias
+			if ref, ok := aliases[n.Alias]; ok {
+				n.Table = ref.table
+				if !strings.EqualFold(ref.database, database) {
+					n.Table = ref.database + "." + ref.table
+				}
+			}
+			used[n.Table] = true
+		}
+		for _, w := range n.Warnings {
+			subject := n.Operation
+			if n.Table != "" {
+				subject = n.Table
+			}
+			result.Warnings = append(result.Warnings, subject+": "+w)
+		}
+		for i := range n.Children {
+			walk(&n.Children[i])
+		}
+	}
+	walk(&result.Plan)
+	if result.Analyzed != nil {
+		// the estimated plan already reported these
+		warnings := result.Warnings
+		walk(result.Analyzed)
+		result.Warnings = warnings
+	}
+	if database == "" || len(used) == 0 {
+		return nil
+	}
+
+	schema, err := s.repo.GetFullSchema(ctx, database)
+	if err != nil {
+		return err
+	}
+	for _, t := range schema.Tables {
+		if used[t.Name] {
+			result.Tables = append(result.Tables, t)
+		}
+	}
+	return nil
+}
+
+// scalar runs a statement returning a single value and returns it as text
+func scalar(ctx context.Context, session domain.SQLSession, stmt string) (string, error) {
+	res, err := session.Run(ctx, stmt, true, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(res.Rows) == 0 || len(res.Rows[0]) == 0 || res.Rows[0][0] == nil {
+		return "", nil
+	}
+	switch v := res.Rows[0][0].(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// explainNote returns the rewritten statement EXPLAIN leaves as note 1003
+func explainNote(ctx context.Context, session domain.SQLSession) (string, error) {
+	res, err := session.Run(ctx, "SHOW WARNINGS", true, 100)
+	if err != nil {
+		return "", err
+	}
+	for _, row := range res.Rows {
+		if len(row) == 3 && fmt.Sprint(row[1]) == "1003" {
+			return fmt.Sprint(row[2]), nil
+		}
+	}
+	return "", fmt.Errorf("no rewritten statement")
+}
+
+type tableRef struct {
+	database, table string
+}
+
+// tableIdent matches `db`.`table` optionally followed by ` alias`, the way
+// tables appear in the server's rewritten statement
+var tableIdent = regexp.MustCompile("`((?:[^`]|``)+)`\\.`((?:[^`]|``)+)`(?: `((?:[^`]|``)+)`)?")
+
+// tableAliases maps the aliases (and plain names) of the tables in a
+// rewritten statement to the tables. Column references `db`.`t`.`col` and
+// function calls are skipped by looking at the characters around a match.
+func tableAliases(note string) map[string]tableRef {
+	aliases := map[string]tableRef{}
+	for _, m := range tableIdent.FindAllStringSubmatchIndex(note, -1) {
+		start, end := m[0], m[1]
+		if start > 0 && note[start-1] == '.' {
+			continue
+		}
+		// without an alias, the match ends at the table; a following "."
+		// or "(" makes it a qualified column or a function
+		if m[6] < 0 && end < len(note) && (note[end] == '.' || note[end] == '(') {
+			continue
+		}
+		unquote := func(a, b int) string { return strings.ReplaceAll(note[a:b], "``", "`") }
+		ref := tableRef{database: unquote(m[2], m[3]), table: unquote(m[4], m[5])}
+		alias := ref.table
+		if m[6] >= 0 {
+			alias = unquote(m[6], m[7])
+		}
+		if _, ok := aliases[alias]; !ok {
+			aliases[alias] = ref
+		}
+	}
+	return aliases
+}
+
+// versionAtLeast compares the leading major.minor.patch of a VERSION()
+func versionAtLeast(version string, major, minor, patch int) bool {
+	parts := strings.SplitN(strings.SplitN(version, "-", 2)[0], ".", 3)
+	want := []int{major, minor, patch}
+	for i, w := range want {
+		if i >= len(parts) {
+			return w == 0
+		}
+		n, _ := strconv.Atoi(strings.TrimRightFunc(parts[i], func(r rune) bool { return r < '0' || r > '9' }))
+		if n != w {
+			return n > w
+		}
+	}
+	return true
+}
@@ -0,0 +1,220 @@
+package database
+
+import (
+	"backend/internal/domain"
+	"backend/internal/sqlutil"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Placeholders returns the distinct :name parameters used in a script, in
+// order of first use. Quoted text, identifiers and comments are skipped.
+func Placeholders(script string) ([]string, error) {
+	var names []string
+	seen := map[string]bool{}
+	_, err := replacePlaceholders(script, func(name string) error {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		return nil
+	})
+	return names, err
+}
+
+// CheckParameters checks that a saved query declares every parameter it
+// uses and that the declared defaults fit their types
+func CheckParameters(script string, params []domain.QueryParameter) error {
+	declared := make(map[string]domain.QueryParameter, len(params))
+	for _, p := range params {
+		declared[p.Name] = p
+		if p.Default != nil {
+			if _, err := paramValue(p, p.Default); err != nil {
+				return fmt.Errorf("default of %v: %w", err, domain.ErrInvalidInput)
+			}
+		}
+	}
+	used, err := Placeholders(script)
+	if err != nil {
+		return err
+	}
+	for _, name := range used {
+		if _, ok := declared[name]; !ok {
+			return fmt.Errorf("parameter :%s is used but not declared: %w", name, domain.ErrInvalidInput)
+		}
+	}
+	return nil
+}
+
+// bindValues converts the supplied values to the declared parameter types,
+// filling in defaults. Values for undeclared parameters are rejected.
+func bindValues(params []domain.QueryParameter, values map[string]interface{}) (map[string]interface{}, error) {
+	declared := make(map[string]bool, len(params))
+	bound := make(map[string]interface{}, len(params))
+	for _, p := range params {
+		declared[p.Name] = true
+		v, ok := values[p.Name]
+		if s, isString := v.(string); !ok || v == nil || isString && s == "" && p.Type != domain.ParamString {
+			v = p.Default
+		}
+		if v == nil {
+			if p.Required {
+				return nil, fmt.Errorf("parameter %s is required: %w", p.Name, domain.ErrInvalidInput)
+			}
+			bound[p.Name] = nil
+			continue
+		}
+		converted, err := paramValue(p, v)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", err, domain.ErrInvalidInput)
+		}
+		bound[p.Name] = converted
+	}
+	for name := range values {
+		if !declared[name] {
+			return nil, fmt.Errorf("unknown parameter %s: %w", name, domain.ErrInvalidInput)
+		}
+	}
+	return bound, nil
+}
+
+// bindStatement replaces the :name placeholders of one statement with ?
+// and returns the values to bind, in order
+func bindStatement(stmt string, values map[string]interface{}) (string, []interface{}, error) {
+	var args []interface{}
+	text, err := replacePlaceholders(stmt, func(name string) error {
+		v, ok := values[name]
+		if !ok {
+			return fmt.Errorf("parameter :%s is not declared: %w", name, domain.ErrInvalidInput)
+		}
+		args = append(args, v)
+		return nil
+	})
+	return text, args, err
+}
+
+// replacePlaceholders calls fn for every :name outside quotes and comments
+// and returns s with each of them replaced by ?
+func replacePlaceholders(s string, fn func(name string) error) (string, error) {
+	var out strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end, err := sqlutil.SkipQuoted(s, i)
+			if err != nil {
+				return "", fmt.Errorf("%v: %w", err, domain.ErrInvalidInput)
+			}
+			out.WriteString(s[i:end])
+			i = end
+			continue
+		case c == '#' || strings.HasPrefix(s[i:], "--") && (i+2 == len(s) || sqlutil.IsSpace(s[i+2])):
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				end = len(s) - i
+			}
+			out.WriteString(s[i : i+end])
+			i += end
+			continue
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return "", fmt.Errorf("unterminated comment: %w", domain.ErrInvalidInput)
+			}
+			end += i + 4
+			out.WriteString(s[i:end])
+			i = end
+			continue
+		case c == ':' && i+1 < len(s) && sqlutil.IsIdentStart(s[i+1]) && (i == 0 || !sqlutil.IsIdentChar(s[i-1]) && s[i-1] != ':'):
+			j := i + 1
+			for j < len(s) && sqlutil.IsIdentChar(s[j]) {
+				j++
+			}
+			if err := fn(s[i+1 : j]); err != nil {
+				return "", err
+			}
+			out.WriteByte('?')
+			i = j
+			continue
+		}
+		out.WriteByte(c)
+		i++
+	}
+	return out.String(), nil
+}
+
+var datetimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+}
+
+// paramValue converts a JSON value to the Go value bound for a parameter.
+// Decimal numbers stay strings so no precision is lost; dates and times are
+// sent in MySQL's literal format.
+func paramValue(p domain.QueryParameter, v interface{}) (interface{}, error) {
+	text := fmt.Sprint(v)
+	switch p.Type {
+	case domain.ParamInt:
+		switch x := v.(type) {
+		case float64:
+			if x == float64(int64(x)) {
+				return int64(x), nil
+			}
+		case json.Number, string:
+			if n, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64); err == nil {
+				return n, nil
+			}
+		}
+		return nil, fmt.Errorf("parameter %s must be an integer, got %v", p.Name, v)
+	case domain.ParamNumber:
+		switch v.(type) {
+		case float64, json.Number, string:
+			text = strings.TrimSpace(text)
+			if _, err := strconv.ParseFloat(text, 64); err == nil {
+				return text, nil
+			}
+		}
+		return nil, fmt.Errorf("parameter %s must be a number, got %v", p.Name, v)
+	case domain.ParamBool:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		switch strings.ToLower(strings.TrimSpace(text)) {
+		case "true", "1", "yes", "on":
+			return true, nil
+		case "false", "0", "no", "off":
+			return false, nil
+		}
+		return nil, fmt.Errorf("parameter %s must be a boolean, got %v", p.Name, v)
+	case domain.ParamDate:
+		if s, ok := v.(string); ok {
+			if t, err := time.Parse("2006-01-02", strings.TrimSpace(s)); err == nil {
+				return t.Format("2006-01-02"), nil
+			}
+		}
+		return nil, fmt.Errorf("parameter %s must be a date (YYYY-MM-DD), got %v", p.Name, v)
+	case domain.ParamDatetime:
+		if s, ok := v.(string); ok {
+			s = strings.TrimSpace(s)
+			for _, layout := range datetimeLayouts {
+				// times without a zone are taken as server-local, like
+				// the connection (loc=Local)
+				if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+					return t.In(time.Local).Format("2006-01-02 15:04:05.999999"), nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("parameter %s must be a datetime (YYYY-MM-DD HH:MM:SS), got %v", p.Name, v)
+	}
+	switch v.(type) {
+	case string, json.Number, float64, bool:
+		return text, nil
+	}
+	return nil, fmt.Errorf("parameter %s must be a string, got %v", p.Name, v)
+}
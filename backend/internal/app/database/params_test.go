@@ -0,0 +1,88 @@
+package database
+
+import (
+	"backend/internal/domain"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestReplacePlaceholders(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		want  string
+		names []string
+	}{
+		{"none", "SELECT 1", "SELECT 1", nil},
+		{"one", "SELECT * FROM t WHERE id = :id", "SELECT * FROM t WHERE id = ?", []string{"id"}},
+		{"repeated", "SELECT :a, :b, :a", "SELECT ?, ?, ?", []string{"a", "b", "a"}},
+		{"at start and end", ":a+:b_2", "?+?", []string{"a", "b_2"}},
+		{"single quotes", "SELECT ':a', 'x\\':b', 'y'':c', :d", "SELECT ':a', 'x\\':b', 'y'':c', ?", []string{"d"}},
+		{"double quotes and backticks", "SELECT \":a\", `:b` FROM t", "SELECT \":a\", `:b` FROM t", nil},
+		{"time literal", "SELECT '12:30:00' = :t", "SELECT '12:30:00' = ?", []string{"t"}},
+		{"assignment", "SET @x := :v", "SET @x := ?", []string{"v"}},
+		{"assignment without spaces", "SELECT @x:=:v", "SELECT @x:=?", []string{"v"}},
+		{"double colon", "SELECT a::b", "SELECT a::b", nil},
+		{"after identifier", "SELECT a:b", "SELECT a:b", nil},
+		{"digit is not a name", "SELECT :1", "SELECT :1", nil},
+		{"dash comment", "SELECT 1 -- :a\n, :b", "SELECT 1 -- :a\n, ?", []string{"b"}},
+		{"dash comment at end", "SELECT :a --", "SELECT ? --", []string{"a"}},
+		{"double dash without space", "SELECT 1--:a", "SELECT 1--?", []string{"a"}},
+		{"hash comment", "SELECT 1 # :a\n+ :b", "SELECT 1 # :a\n+ ?", []string{"b"}},
+		{"block comment", "SELECT /* :a */ :b", "SELECT /* :a */ ?", []string{"b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			got, err := replacePlaceholders(tt.in, func(name string) error {
+				names = append(names, name)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("replacePlaceholders(%q): %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("replacePlaceholders(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			if !reflect.DeepEqual(names, tt.names) {
+				t.Errorf("replacePlaceholders(%q) names = %q, want %q", tt.in, names, tt.names)
+			}
+		})
+	}
+}
+
+func TestReplacePlaceholdersErrors(t *testing.T) {
+	for _, in := range []string{"SELECT ':a", "SELECT /* :a"} {
+		_, err := replacePlaceholders(in, func(string) error { return nil })
+		if !errors.Is(err, domain.ErrInvalidInput) {
+			t.Errorf("replacePlaceholders(%q) error = %v, want ErrInvalidInput", in, err)
+		}
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	got, err := Placeholders("SELECT :b, ':c', :a;\nSELECT :b -- :d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Placeholders = %q, want %q", got, want)
+	}
+}
+
+func TestBindStatement(t *testing.T) {
+	text, args, err := bindStatement("SELECT :b, :a, :b", map[string]interface{}{"a": 1, "b": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "SELECT ?, ?, ?" {
+		t.Errorf("text = %q", text)
+	}
+	if want := []interface{}{"x", 1, "x"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+	if _, _, err := bindStatement("SELECT :missing", nil); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("undeclared parameter error = %v, want ErrInvalidInput", err)
+	}
+}
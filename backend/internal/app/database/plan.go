@@ -0,0 +1,284 @@
+package database
+
+import (
+	"backend/internal/domain"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Plan warnings, also matched by the frontend
+const (
+	warnFullScan      = "full table scan"
+	warnFullIndexScan = "full index scan"
+	warnFilesort      = "filesort"
+	warnTemporary     = "temporary table"
+	warnJoinBuffer    = "join buffer (no index for the join)"
+	warnDependent     = "dependent subquery (runs once per outer row)"
+)
+
+// planChildKeys are the members of an EXPLAIN FORMAT=JSON object that hold
+// nested plan steps, in the order they are shown. Both the MySQL and the
+// MariaDB spelling are listed.
+var planChildKeys = []string{
+	"ordering_operation", "grouping_operation", "duplicates_removal", "windowing",
+	"filesort", "temporary_table", "read_sorted_file",
+	"union_result", "nested_loop", "table", "block-nl-join", "materialized_from_subquery",
+	"materialized", "query_specifications", "query_block",
+	"attached_subqueries", "optimized_away_subqueries", "having_subqueries", "select_list_subqueries",
+	"order_by_subqueries", "group_by_subqueries", "update_value_subqueries", "subqueries",
+}
+
+// planFromJSON normalizes EXPLAIN FORMAT=JSON (or MariaDB's ANALYZE
+// FORMAT=JSON, whose r_* members carry the actual figures)
+func planFromJSON(doc map[string]interface{}) domain.PlanNode {
+	if qb, ok := doc["query_block"].(map[string]interface{}); ok {
+		return jsonNode("query_block", qb)
+	}
+	return jsonNode("plan", doc)
+}
+
+func jsonNode(key string, obj map[string]interface{}) domain.PlanNode {
+	n := domain.PlanNode{Operation: strings.ReplaceAll(key, "_", " ")}
+	switch key {
+	case "query_block":
+		n.Operation = "select"
+		if id, ok := number(obj["select_id"]); ok {
+			n.Operation = fmt.Sprintf("select #%d", int(*id))
+		}
+		if msg, ok := obj["message"].(string); ok {
+			n.Operation += " (" + msg + ")"
+		}
+		n.Cost = costOf(obj, "query_cost")
+	case "table", "read_sorted_file":
+		tableNode(&n, obj)
+	case "ordering_operation":
+		n.Operation = "order by"
+	case "grouping_operation":
+		n.Operation = "group by"
+	case "duplicates_removal":
+		n.Operation = "distinct"
+	case "windowing":
+		n.Operation = "window"
+	case "union_result":
+		n.Operation = "union"
+		if t, ok := obj["table_name"].(string); ok {
+			n.Alias = t
+		}
+	case "filesort":
+		n.Warnings = append(n.Warnings, warnFilesort)
+	case "temporary_table":
+		n.Warnings = append(n.Warnings, warnTemporary)
+	case "materialized_from_subquery", "materialized":
+		n.Operation = "materialize"
+	}
+	if flag(obj["using_filesort"]) {
+		n.Warnings = append(n.Warnings, warnFilesort)
+	}
+	if flag(obj["using_temporary_table"]) {
+		n.Warnings = append(n.Warnings, warnTemporary)
+	}
+	if flag(obj["dependent"]) {
+		n.Warnings = append(n.Warnings, warnDependent)
+	}
+	n.Children = jsonChildren(obj)
+	return n
+}
+
+func jsonChildren(obj map[string]interface{}) []domain.PlanNode {
+	var children []domain.PlanNode
+	for _, key := range planChildKeys {
+		switch v := obj[key].(type) {
+		case map[string]interface{}:
+			children = append(children, jsonNode(key, v))
+		case []interface{}:
+			var items []domain.PlanNode
+			for _, item := range v {
+				m, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				// array items wrap a single step: {"table": {...}},
+				// {"query_block": {...}} or a subquery with its flags
+				if inner := jsonChildren(m); len(inner) == 1 && !flag(m["dependent"]) {
+					items = append(items, inner[0])
+				} else {
+					sub := domain.PlanNode{Operation: "subquery", Children: inner}
+					if flag(m["dependent"]) {
+						sub.Warnings = []string{warnDependent}
+					}
+					items = append(items, sub)
+				}
+			}
+			if key == "nested_loop" {
+				children = append(children, domain.PlanNode{Operation: "nested loop", Children: items})
+			} else {
+				children = append(children, items...)
+			}
+		}
+	}
+	return children
+}
+
+func tableNode(n *domain.PlanNode, obj map[string]interface{}) {
+	n.Operation = "table"
+	if t, ok := obj["table_name"].(string); ok {
+		n.Alias = t
+	}
+	n.AccessType, _ = obj["access_type"].(string)
+	n.Key, _ = obj["key"].(string)
+	if keys, ok := obj["possible_keys"].([]interface{}); ok {
+		for _, k := range keys {
+			n.PossibleKeys = append(n.PossibleKeys, fmt.Sprint(k))
+		}
+	}
+	n.Condition, _ = obj["attached_condition"].(string)
+	n.Cost = costOf(obj, "prefix_cost")
+	if rows, ok := number(obj["rows_examined_per_scan"]); ok {
+		n.EstimatedRows = rows
+	} else if rows, ok := number(obj["rows"]); ok {
+		n.EstimatedRows = rows
+	}
+	n.Filtered, _ = number(obj["filtered"])
+	n.ActualRows, _ = number(obj["r_rows"])
+	n.Loops, _ = number(obj["r_loops"])
+	if t, ok := number(obj["r_total_time_ms"]); ok && n.Loops != nil && *n.Loops > 0 {
+		perLoop := *t / *n.Loops
+		n.ActualTimeMs = &perLoop
+	}
+
+	switch n.AccessType {
+	case "ALL":
+		n.Warnings = append(n.Warnings, warnFullScan)
+	case "index":
+		n.Warnings = append(n.Warnings, warnFullIndexScan)
+	}
+	if obj["using_join_buffer"] != nil {
+		n.Warnings = append(n.Warnings, warnJoinBuffer)
+	}
+}
+
+// costOf reads cost_info.<name>
+func costOf(obj map[string]interface{}, name string) *float64 {
+	info, ok := obj["cost_info"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	cost, _ := number(info[name])
+	return cost
+}
+
+// number reads a plan figure; MySQL writes costs and percentages as strings
+func number(v interface{}) (*float64, bool) {
+	var f float64
+	var err error
+	switch x := v.(type) {
+	case json.Number:
+		f, err = x.Float64()
+	case string:
+		f, err = strconv.ParseFloat(x, 64)
+	case float64:
+		f = x
+	default:
+		return nil, false
+	}
+	if err != nil {
+		return nil, false
+	}
+	return &f, true
+}
+
+func flag(v interface{}) bool {
+	b, _ := v.(bool)
+	return b
+}
+
+// treeLine is one line of EXPLAIN ANALYZE (FORMAT=TREE) output:
+//
+//	-> Table scan on o  (cost=1.75 rows=5) (actual time=0.03..0.04 rows=5 loops=1)
+var treeLine = regexp.MustCompile(`^(\s*)-> (.*?)` +
+	`(?:\s+\(cost=([0-9.e+-]+) rows=([0-9.e+-]+)\))?` +
+	`(?:\s+\(actual time=([0-9.e+-]+)\.\.([0-9.e+-]+) rows=([0-9.e+-]+) loops=([0-9.e+-]+)\)|\s+\(never executed\))?\s*$`)
+
+// treeTable finds the table alias a tree step reads
+var treeTable = regexp.MustCompile(`(?:scan|lookup|search) on (\S+)|Constant row from (\S+)`)
+
+// planFromTree parses the indented tree of EXPLAIN ANALYZE. Children are
+// indented four more spaces than their parent.
+func planFromTree(text string) *domain.PlanNode {
+	root := &domain.PlanNode{Operation: "plan"}
+	type frame struct {
+		indent int
+		node   *domain.PlanNode
+	}
+	stack := []frame{{indent: -1, node: root}}
+	for _, line := range strings.Split(text, "\n") {
+		m := treeLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		n := treeNode(m)
+		indent := len(m[1])
+		for len(stack) > 1 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1].node
+		parent.Children = append(parent.Children, n)
+		stack = append(stack, frame{indent: indent, node: &parent.Children[len(parent.Children)-1]})
+	}
+	if len(root.Children) == 1 {
+		return &root.Children[0]
+	}
+	return root
+}
+
+func treeNode(m []string) domain.PlanNode {
+	desc := m[2]
+	n := domain.PlanNode{Operation: desc}
+	parse := func(s string) *float64 {
+		f, ok := number(s)
+		if !ok {
+			return nil
+		}
+		return f
+	}
+	n.Cost, n.EstimatedRows = parse(m[3]), parse(m[4])
+	n.ActualTimeMs, n.ActualRows, n.Loops = parse(m[6]), parse(m[7]), parse(m[8])
+
+	if t := treeTable.FindStringSubmatch(desc); t != nil {
+		n.Alias = t[1] + t[2]
+	}
+	if i := strings.Index(desc, " using "); i >= 0 && n.Alias != "" {
+		n.Key = strings.Fields(desc[i+len(" using "):])[0]
+	}
+	switch {
+	case strings.Contains(desc, "Table scan on"):
+		n.AccessType = "ALL"
+		n.Warnings = append(n.Warnings, warnFullScan)
+	case strings.Contains(desc, "ndex range scan on"):
+		n.AccessType = "range"
+	case strings.Contains(desc, "ndex scan on"):
+		n.AccessType = "index"
+		n.Warnings = append(n.Warnings, warnFullIndexScan)
+	case strings.Contains(desc, "Single-row index lookup on"), strings.Contains(desc, "Single-row covering index lookup on"):
+		n.AccessType = "eq_ref"
+	case strings.Contains(desc, "ndex lookup on"):
+		n.AccessType = "ref"
+	case strings.Contains(desc, "Full-text index search on"):
+		n.AccessType = "fulltext"
+	case strings.HasPrefix(desc, "Constant row from"):
+		n.AccessType = "const"
+	case strings.HasPrefix(desc, "Filter: "):
+		n.Condition = strings.TrimPrefix(desc, "Filter: ")
+	case strings.HasPrefix(desc, "Sort"):
+		n.Warnings = append(n.Warnings, warnFilesort)
+	case strings.HasPrefix(desc, "Temporary table"), strings.HasPrefix(desc, "Materialize"):
+		n.Warnings = append(n.Warnings, warnTemporary)
+	}
+	if strings.Contains(desc, "dependent") {
+		n.Warnings = append(n.Warnings, warnDependent)
+	}
+	return n
+}
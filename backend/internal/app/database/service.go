@@ -1,16 +1,28 @@
 package database
 
 import (
+	"backend/internal/app/data"
 	"backend/internal/domain"
+	"backend/internal/sqlutil"
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
 )
 
 type databaseService struct {
 	repo domain.SchemaRepository
+	// queryTimeout limits each console statement; 0 means no limit
+	queryTimeout time.Duration
+
+	mu      sync.Mutex
+	running map[string]context.CancelFunc // console queries by QueryID
 }
 
-func NewDatabaseService(repo domain.SchemaRepository) domain.DatabaseService {
-	return &databaseService{repo: repo}
+func NewDatabaseService(repo domain.SchemaRepository, queryTimeout time.Duration) domain.DatabaseService {
+	return &databaseService{repo: repo, queryTimeout: queryTimeout, running: make(map[string]context.CancelFunc)}
 }
 
 func (s *databaseService) List(ctx context.Context) ([]string, error) {
@@ -25,6 +37,173 @@ func (s *databaseService) Drop(ctx context.Context, name string) error {
 	return s.repo.DropDatabase(ctx, name)
 }
 
-func (s *databaseService) Execute(ctx context.Context, query string) error {
-	return s.repo.ExecuteDDL(ctx, query)
+const (
+	defaultMaxRows = 1000
+	maxMaxRows     = 10000
+	maxStatements  = 1000
+)
+
+// Execute runs a console script statement by statement on one dedicated
+// connection and reports columns, typed rows, affected rows, insert id and
+// timing for each. A failing statement is reported in its result, not as
+// an error; the statements after it are skipped unless ContinueOnError.
+// A cancelled or timed out script always stops.
+func (s *databaseService) Execute(ctx context.Context, req domain.ConsoleRequest) (*domain.QueryResult, error) {
+	stmts, err := SplitStatements(req.Query)
+	if err != nil {
+		return nil, err
+	}
+	if len(stmts) == 0 {
+		return nil, fmt.Errorf("query is empty: %w", domain.ErrInvalidInput)
+	}
+	if len(stmts) > maxStatements {
+		return nil, fmt.Errorf("script has %d statements, at most %d are allowed: %w", len(stmts), maxStatements, domain.ErrInvalidInput)
+	}
+	if s.repo.ReadOnly() {
+		for _, stmt := range stmts {
+			if !allowedReadOnly(stmt.Text) {
+				return nil, fmt.Errorf("line %d: %s statements are not allowed: %w",
+					stmt.Line, strings.ToUpper(sqlutil.FirstKeyword(stmt.Text)), domain.ErrReadOnly)
+			}
+		}
+	}
+	var values map[string]interface{}
+	if len(req.Parameters) > 0 || len(req.Params) > 0 {
+		if values, err = bindValues(req.Parameters, req.Params); err != nil {
+			return nil, err
+		}
+	}
+	texts := make([]string, len(stmts))
+	args := make([][]interface{}, len(stmts))
+	for i, stmt := range stmts {
+		texts[i] = stmt.Text
+		if values != nil {
+			if texts[i], args[i], err = bindStatement(stmt.Text, values); err != nil {
+				return nil, fmt.Errorf("line %d: %w", stmt.Line, err)
+			}
+		}
+	}
+	maxRows := req.MaxRows
+	if maxRows <= 0 {
+		maxRows = defaultMaxRows
+	}
+	if maxRows > maxMaxRows {
+		maxRows = maxMaxRows
+	}
+
+	timeout := s.queryTimeout
+	if req.TimeoutSeconds < 0 {
+		return nil, fmt.Errorf("timeout_seconds must not be negative: %w", domain.ErrInvalidInput)
+	}
+	if req.TimeoutSeconds > 0 {
+		timeout = time.Duration(req.TimeoutSeconds) * time.Second
+	}
+	if req.QueryID != "" {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		if err := s.register(req.QueryID, cancel); err != nil {
+			return nil, err
+		}
+		defer s.unregister(req.QueryID)
+	}
+
+	session, err := s.repo.OpenSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	if req.Database != "" {
+		if err := session.Use(ctx, req.Database); err != nil {
+			return nil, fmt.Errorf("selecting database %s: %v: %w", req.Database, err, domain.ErrInvalidInput)
+		}
+	}
+
+	start := time.Now()
+	result := &domain.QueryResult{Results: make([]domain.StatementResult, 0, len(stmts))}
+	failed, stopped := false, false
+	for i, stmt := range stmts {
+		if stopped || failed && !req.ContinueOnError {
+			result.Results = append(result.Results, domain.StatementResult{
+				Index: i, Line: stmt.Line, Statement: stmt.Text, Type: StatementType(stmt.Text), Skipped: true,
+			})
+			continue
+		}
+		res, err := runStatement(ctx, session, i, texts[i], maxRows, timeout, args[i]...)
+		res.Line, res.Statement = stmt.Line, stmt.Text
+		failed = failed || res.Error != ""
+		stopped = err != nil
+		result.Results = append(result.Results, res)
+	}
+	result.DurationMs = millis(time.Since(start))
+	return result, nil
+}
+
+// Cancel stops a running console query. The session kills the statement
+// on the server when its context is cancelled.
+func (s *databaseService) Cancel(ctx context.Context, queryID string) error {
+	s.mu.Lock()
+	cancel, ok := s.running[queryID]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("query %q is not running: %w", queryID, domain.ErrNotFound)
+	}
+	cancel()
+	return nil
+}
+
+func (s *databaseService) register(queryID string, cancel context.CancelFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.running[queryID]; ok {
+		return fmt.Errorf("query %q is already running: %w", queryID, domain.ErrInvalidInput)
+	}
+	s.running[queryID] = cancel
+	return nil
+}
+
+func (s *databaseService) unregister(queryID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, queryID)
+}
+
+// runStatement executes one statement and encodes its rows for JSON. The
+// error is only set when the script must stop: the statement was
+// cancelled or ran out of time.
+func runStatement(ctx context.Context, session domain.SQLSession, index int, stmt string, maxRows int, timeout time.Duration, args ...interface{}) (domain.StatementResult, error) {
+	stmtCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		stmtCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	stmtType := StatementType(stmt)
+	start := time.Now()
+	res, err := session.Run(stmtCtx, stmt, returnsRows(stmtType), maxRows, args...)
+	elapsed := time.Since(start)
+	var stop error
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		stop = ctx.Err()
+		res = &domain.StatementResult{Error: "query cancelled"}
+	case errors.Is(stmtCtx.Err(), context.DeadlineExceeded):
+		stop = stmtCtx.Err()
+		res = &domain.StatementResult{Error: fmt.Sprintf("query timed out after %s", timeout)}
+	default:
+		res = &domain.StatementResult{Error: err.Error()}
+	}
+	res.Index, res.Statement, res.Type = index, stmt, stmtType
+	res.DurationMs = millis(elapsed)
+	for _, row := range res.Rows {
+		for i, v := range row {
+			row[i] = data.EncodeValue(res.Columns[i].Type, v)
+		}
+	}
+	return *res, stop
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
 }
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"backend/internal/domain"
+	"backend/internal/sqlutil"
+	"fmt"
+	"strings"
+)
+
+// Statement is one statement of a script with the line it starts on
+type Statement struct {
+	Text string
+	Line int
+}
+
+// SplitStatements splits a script the way the mysql client does: on the
+// current delimiter outside quotes and comments, with DELIMITER lines
+// changing the delimiter (so procedure bodies can contain ";"). DELIMITER
+// lines themselves are not returned, nor are statements that are only
+// comments.
+func SplitStatements(script string) ([]Statement, error) {
+	var (
+		out       []Statement
+		delimiter = ";"
+		buf       strings.Builder
+		line      = 1
+		startLine = 0
+		content   = false // buf holds more than whitespace and comments
+	)
+	flush := func() {
+		if content {
+			out = append(out, Statement{Text: strings.TrimSpace(buf.String()), Line: startLine})
+		}
+		buf.Reset()
+		startLine, content = 0, false
+	}
+
+	for i := 0; i < len(script); {
+		// DELIMITER is a client command: only recognised at the start of a
+		// statement, and it runs to the end of the line
+		if !content && atLineStart(script, i) {
+			if d, next, ok := delimiterCommand(script, i); ok {
+				if d == "" {
+					return nil, fmt.Errorf("line %d: DELIMITER needs a value: %w", line, domain.ErrInvalidInput)
+				}
+				delimiter = d
+				line += strings.Count(script[i:next], "\n")
+				buf.Reset()
+				startLine = 0
+				i = next
+				continue
+			}
+		}
+
+		c := script[i]
+		if startLine == 0 && !sqlutil.IsSpace(c) {
+			startLine = line
+		}
+		switch {
+		case strings.HasPrefix(script[i:], delimiter):
+			flush()
+			i += len(delimiter)
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			content = true
+			end, err := sqlutil.SkipQuoted(script, i)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v: %w", line, err, domain.ErrInvalidInput)
+			}
+			line += strings.Count(script[i:end], "\n")
+			buf.WriteString(script[i:end])
+			i = end
+			continue
+		case c == '#' || strings.HasPrefix(script[i:], "--") && (i+2 == len(script) || sqlutil.IsSpace(script[i+2])):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			buf.WriteString(script[i : i+end])
+			i += end
+			continue
+		case strings.HasPrefix(script[i:], "/*"):
+			// /*! ... */ is executable by MySQL, not a comment
+			content = content || strings.HasPrefix(script[i:], "/*!")
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment: %w", line, domain.ErrInvalidInput)
+			}
+			end += i + 4
+			line += strings.Count(script[i:end], "\n")
+			buf.WriteString(script[i:end])
+			i = end
+			continue
+		}
+		if c == '\n' {
+			line++
+		}
+		content = content || !sqlutil.IsSpace(c)
+		buf.WriteByte(c)
+		i++
+	}
+	flush()
+	return out, nil
+}
+
+func atLineStart(s string, i int) bool {
+	for j := i - 1; j >= 0; j-- {
+		switch s[j] {
+		case '\n':
+			return true
+		case ' ', '\t', '\r':
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// delimiterCommand parses "DELIMITER x" at i, returning the new delimiter
+// and the index after the line
+func delimiterCommand(s string, i int) (string, int, bool) {
+	rest := strings.TrimLeft(s[i:], " \t\r\n")
+	const kw = "DELIMITER"
+	if len(rest) <= len(kw) || !strings.EqualFold(rest[:len(kw)], kw) || !sqlutil.IsSpace(rest[len(kw)]) {
+		return "", 0, false
+	}
+	start := len(s) - len(rest)
+	end := strings.IndexByte(s[start:], '\n')
+	if end < 0 {
+		end = len(s)
+	} else {
+		end += start + 1
+	}
+	fields := strings.Fields(s[start+len(kw) : end])
+	if len(fields) == 0 {
+		return "", end, true
+	}
+	return fields[0], end, true
+}
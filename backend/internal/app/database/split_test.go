@@ -0,0 +1,114 @@
+package database
+
+import (
+	"backend/internal/domain"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []Statement
+	}{
+		{
+			name:   "single without delimiter",
+			script: "SELECT 1",
+			want:   []Statement{{"SELECT 1", 1}},
+		},
+		{
+			name:   "several with lines",
+			script: "SELECT 1;\nSELECT 2;\n\nSELECT 3",
+			want:   []Statement{{"SELECT 1", 1}, {"SELECT 2", 2}, {"SELECT 3", 4}},
+		},
+		{
+			name:   "semicolon in quotes",
+			script: `SELECT 'a;b', "c;d", ` + "`e;f`" + `; SELECT 2`,
+			want:   []Statement{{`SELECT 'a;b', "c;d", ` + "`e;f`", 1}, {"SELECT 2", 1}},
+		},
+		{
+			name:   "escaped and doubled quotes",
+			script: `SELECT 'it\'s;', 'it''s;'; SELECT 2`,
+			want:   []Statement{{`SELECT 'it\'s;', 'it''s;'`, 1}, {"SELECT 2", 1}},
+		},
+		{
+			name:   "semicolon in comments",
+			script: "SELECT 1 -- one; two\n# three; four\n/* five; six */;SELECT 2",
+			want:   []Statement{{"SELECT 1 -- one; two\n# three; four\n/* five; six */", 1}, {"SELECT 2", 3}},
+		},
+		{
+			name:   "double dash without space is not a comment",
+			script: "SELECT 1--1; SELECT 2",
+			want:   []Statement{{"SELECT 1--1", 1}, {"SELECT 2", 1}},
+		},
+		{
+			name:   "double dash at end of script",
+			script: "SELECT 1; --",
+			want:   []Statement{{"SELECT 1", 1}},
+		},
+		{
+			name:   "comment only statements are dropped",
+			script: "-- nothing\n; /* still nothing */ ;SELECT 1",
+			want:   []Statement{{"SELECT 1", 2}},
+		},
+		{
+			name:   "executable comment is a statement",
+			script: "/*!40101 SET NAMES utf8 */;",
+			want:   []Statement{{"/*!40101 SET NAMES utf8 */", 1}},
+		},
+		{
+			name: "procedure body with DELIMITER",
+			script: "DELIMITER //\n" +
+				"CREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END//\n" +
+				"DELIMITER ;\n" +
+				"CALL p();",
+			want: []Statement{
+				{"CREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END", 2},
+				{"CALL p()", 4},
+			},
+		},
+		{
+			name: "DELIMITER changed twice",
+			script: "delimiter $$\nSELECT 1; SELECT 2$$\n" +
+				"DELIMITER //\nSELECT 3$$4//\n" +
+				"DELIMITER ;\nSELECT 5",
+			want: []Statement{{"SELECT 1; SELECT 2", 2}, {"SELECT 3$$4", 4}, {"SELECT 5", 6}},
+		},
+		{
+			name:   "DELIMITER inside a statement is text",
+			script: "SELECT 'x' AS\nDELIMITER ;",
+			want:   []Statement{{"SELECT 'x' AS\nDELIMITER", 1}},
+		},
+		{
+			name:   "delimiter in quotes under DELIMITER",
+			script: "DELIMITER //\nSELECT '//'//",
+			want:   []Statement{{"SELECT '//'", 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitStatements(tt.script)
+			if err != nil {
+				t.Fatalf("SplitStatements(%q): %v", tt.script, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitStatements(%q)\n got %q\nwant %q", tt.script, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitStatementsErrors(t *testing.T) {
+	for _, script := range []string{
+		"SELECT 'open",
+		"SELECT `open",
+		"SELECT 1 /* open",
+		"DELIMITER\nSELECT 1",
+	} {
+		if _, err := SplitStatements(script); !errors.Is(err, domain.ErrInvalidInput) {
+			t.Errorf("SplitStatements(%q) error = %v, want ErrInvalidInput", script, err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package database
+
+import (
+	"backend/internal/domain"
+	"backend/internal/sqlutil"
+	"strings"
+)
+
+// StatementType classifies a statement by its verb (see sqlutil.Verb), so
+// WITH statements count as the statement after their common table
+// expressions.
+func StatementType(stmt string) string {
+	switch sqlutil.Verb(stmt) {
+	case "SELECT", "SHOW", "DESCRIBE", "DESC", "EXPLAIN", "TABLE", "VALUES", "HELP",
+		"ANALYZE", "CHECK", "CHECKSUM", "OPTIMIZE", "REPAIR":
+		return domain.StmtSelect
+	case "INSERT", "REPLACE":
+		return domain.StmtInsert
+	case "UPDATE":
+		return domain.StmtUpdate
+	case "DELETE":
+		return domain.StmtDelete
+	case "CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME":
+		return domain.StmtDDL
+	case "BEGIN", "START", "COMMIT", "ROLLBACK", "SAVEPOINT", "RELEASE", "XA":
+		return domain.StmtTransaction
+	case "USE", "SET":
+		return domain.StmtSession
+	case "CALL":
+		return domain.StmtCall
+	}
+	return domain.StmtOther
+}
+
+// allowedReadOnly reports whether a statement may run on a read-only
+// connection. The session's read-only transaction rejects writes anyway;
+// this also keeps the transaction from being ended or switched to read
+// write, and gives a clear error up front. Procedures are refused: one
+// that commits or runs DDL would end the transaction.
+func allowedReadOnly(stmt string) bool {
+	switch StatementType(stmt) {
+	case domain.StmtSelect:
+		return sqlutil.IsRead(stmt)
+	case domain.StmtSession:
+		if strings.EqualFold(sqlutil.FirstKeyword(stmt), "USE") {
+			return true
+		}
+		return allowedSet(stmt)
+	}
+	return false
+}
+
+// readOnlyLocked are the session variables a read-only session keeps:
+// changing them would end its transaction, make the next one read write
+// or change what the server logs
+var readOnlyLocked = map[string]bool{
+	"autocommit":            true,
+	"completion_type":       true,
+	"transaction_read_only": true,
+	"tx_read_only":          true,
+	"transaction_isolation": true,
+	"tx_isolation":          true,
+	"sql_log_bin":           true,
+}
+
+// allowedSet reports whether every assignment of a SET statement is safe
+// on a read-only connection: user variables, NAMES and CHARACTER SET, and
+// session variables outside readOnlyLocked. GLOBAL and PERSIST variables,
+// PASSWORD, ROLE and TRANSACTION are refused.
+func allowedSet(stmt string) bool {
+	s := strings.TrimLeft(sqlutil.SkipComments(stmt), "( \t\r\n")
+	if !strings.EqualFold(sqlutil.FirstKeyword(s), "SET") {
+		return false
+	}
+	parts, ok := splitAssignments(s[len("SET"):])
+	if !ok || len(parts) == 0 {
+		return false
+	}
+	for _, part := range parts {
+		if !allowedAssignment(strings.TrimSpace(sqlutil.SkipComments(part))) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitAssignments splits the assignment list of a SET statement at the
+// commas outside quotes, parentheses and comments. Executable /*! */
+// comments could hide further assignments, so they make it fail.
+func splitAssignments(s string) ([]string, bool) {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end, err := sqlutil.SkipQuoted(s, i)
+			if err != nil {
+				return nil, false
+			}
+			i = end
+			continue
+		case c == '#' || strings.HasPrefix(s[i:], "--") && (i+2 == len(s) || sqlutil.IsSpace(s[i+2])):
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				end = len(s) - i
+			}
+			i += end
+			continue
+		case strings.HasPrefix(s[i:], "/*!"):
+			return nil, false
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return nil, false
+			}
+			i += end + 4
+			continue
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+		i++
+	}
+	return append(parts, s[start:]), true
+}
+
+// allowedAssignment checks one "target = value" of a SET statement
+func allowedAssignment(a string) bool {
+	if strings.HasPrefix(a, "@@") {
+		a = a[2:]
+		dot := strings.IndexByte(a, '.')
+		if dot >= 0 {
+			switch strings.ToUpper(a[:dot]) {
+			case "SESSION", "LOCAL":
+				a = a[dot+1:]
+			default:
+				// GLOBAL, PERSIST and PERSIST_ONLY
+				return false
+			}
+		}
+	} else if strings.HasPrefix(a, "@") {
+		return true
+	} else {
+		switch word := strings.ToUpper(leadingWord(a)); word {
+		case "NAMES", "CHARSET":
+			return true
+		case "CHARACTER":
+			return strings.EqualFold(leadingWord(strings.TrimSpace(a[len(word):])), "SET")
+		case "SESSION", "LOCAL":
+			a = strings.TrimSpace(a[len(word):])
+		}
+	}
+
+	name, rest := leadingWord(a), ""
+	if strings.HasPrefix(a, "`") {
+		end, err := sqlutil.SkipQuoted(a, 0)
+		if err != nil {
+			return false
+		}
+		name, rest = strings.ReplaceAll(a[1:end-1], "``", "`"), a[end:]
+	} else {
+		rest = a[len(name):]
+	}
+	if name == "" || readOnlyLocked[strings.ToLower(name)] {
+		return false
+	}
+	switch strings.ToUpper(name) {
+	// statements of their own, not variables
+	case "GLOBAL", "PERSIST", "PERSIST_ONLY", "PASSWORD", "ROLE", "DEFAULT", "TRANSACTION", "RESOURCE":
+		return false
+	}
+	rest = strings.TrimSpace(rest)
+	return strings.HasPrefix(rest, "=") || strings.HasPrefix(rest, ":=")
+}
+
+// leadingWord returns the identifier at the start of s
+func leadingWord(s string) string {
+	end := 0
+	for end < len(s) && sqlutil.IsIdentChar(s[end]) {
+		end++
+	}
+	return s[:end]
+}
+
+// returnsRows reports whether a statement type is run as a query.
+// Procedures may or may not return a result set; querying covers both.
+func returnsRows(stmtType string) bool {
+	return stmtType == domain.StmtSelect || stmtType == domain.StmtCall
+}
@@ -0,0 +1,79 @@
+package database
+
+import "testing"
+
+func TestAllowedReadOnly(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want bool
+	}{
+		{"SELECT * FROM t", true},
+		{"/* report */ select 1", true},
+		{"(SELECT 1) UNION (SELECT 2)", true},
+		{"WITH c AS (SELECT 1) SELECT * FROM c", true},
+		{"SHOW TABLES", true},
+		{"EXPLAIN SELECT 1", true},
+		{"SELECT * FROM t INTO OUTFILE '/tmp/x'", false},
+		{"SELECT * FROM t INTO DUMPFILE '/tmp/x'", false},
+		{"ANALYZE TABLE t", false},
+		{"OPTIMIZE TABLE t", false},
+		{"WITH c AS (SELECT 1) DELETE FROM t", false},
+		{"INSERT INTO t VALUES (1)", false},
+		{"UPDATE t SET a = 1", false},
+		{"DROP TABLE t", false},
+		{"COMMIT", false},
+		{"START TRANSACTION READ WRITE", false},
+		{"CALL purge()", false},
+		{"DO 1", false},
+
+		{"USE shop", true},
+		{"SET @a = 1", true},
+		{"SET @a := (SELECT COUNT(*) FROM t), @b = 'x,autocommit=1'", true},
+		{"SET @`odd name` = 1", true},
+		{"SET NAMES utf8mb4", true},
+		{"SET NAMES utf8mb4 COLLATE utf8mb4_bin", true},
+		{"SET CHARACTER SET utf8mb4", true},
+		{"SET CHARSET utf8mb4", true},
+		{"SET SESSION sql_mode = 'ANSI'", true},
+		{"SET LOCAL max_execution_time = 1000", true},
+		{"SET @@session.time_zone = '+00:00'", true},
+		{"SET @@sql_select_limit = 10, @x = 2", true},
+		{"set `time_zone` = '+00:00'", true},
+		{"SET max_execution_time=1000 -- , autocommit=1", true},
+
+		{"SET autocommit = 1", false},
+		{"SET autocommit=1", false},
+		{"set AUTOCOMMIT = ON", false},
+		{"SET SESSION autocommit = 1", false},
+		{"SET @@autocommit = 1", false},
+		{"SET @@session.autocommit = 1", false},
+		{"SET `autocommit` = 1", false},
+		{"SET @a = 1, autocommit = 1", false},
+		{"SET @a = (1, 2), autocommit = 1", false},
+		{"SET /* x */ autocommit = 1", false},
+		{"SET @a = 1 /*!, autocommit = 1 */", false},
+		{"SET completion_type = RELEASE", false},
+		{"SET transaction_read_only = OFF", false},
+		{"SET tx_read_only = 0", false},
+		{"SET sql_log_bin = 0", false},
+		{"SET TRANSACTION READ WRITE", false},
+		{"SET SESSION TRANSACTION ISOLATION LEVEL READ COMMITTED", false},
+		{"SET GLOBAL event_scheduler = ON", false},
+		{"SET @@global.event_scheduler = ON", false},
+		{"SET PERSIST max_connections = 10", false},
+		{"SET PERSIST_ONLY max_connections = 10", false},
+		{"SET @@persist.max_connections = 10", false},
+		{"SET PASSWORD = 'x'", false},
+		{"SET PASSWORD FOR root = 'x'", false},
+		{"SET ROLE ALL", false},
+		{"SET DEFAULT ROLE ALL TO root", false},
+		{"SET RESOURCE GROUP g", false},
+		{"SET @a = 'open", false},
+		{"SET", false},
+	}
+	for _, tt := range tests {
+		if got := allowedReadOnly(tt.stmt); got != tt.want {
+			t.Errorf("allowedReadOnly(%q) = %v, want %v", tt.stmt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,527 @@
+package layout
+
+import (
+	"backend/internal/domain"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Auto layout algorithms
+const (
+	AlgorithmLayered = "layered"
+	AlgorithmForce   = "force"
+	AlgorithmGrid    = "grid"
+)
+
+const (
+	nodeWidth     = 240.0
+	headerHeight  = 40.0
+	rowHeight     = 24.0
+	gapX          = 80.0
+	gapY          = 100.0
+	maxRowWidth   = 6000.0
+	canvasPadding = 40.0
+)
+
+type size struct{ w, h float64 }
+
+// graph is the table/relation graph of a schema with stable node ids
+// (tables sorted by name) so every run produces the same positions.
+type graph struct {
+	names []string
+	sizes []size
+	// parents[i] are the tables that table i references (child -> parent)
+	parents [][]int
+	// adj is the undirected, de-duplicated neighbour list
+	adj [][]int
+}
+
+func newGraph(schema *domain.DatabaseSchema, current *domain.Layout) *graph {
+	g := &graph{}
+	index := make(map[string]int)
+
+	tables := append([]domain.TableSchema(nil), schema.Tables...)
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	for i, t := range tables {
+		index[t.Name] = i
+		g.names = append(g.names, t.Name)
+		sz := size{w: nodeWidth, h: headerHeight + rowHeight*float64(len(t.Columns))}
+		if n, ok := current.Nodes[t.Name]; ok {
+			if n.Width > 0 {
+				sz.w = n.Width
+			}
+			if n.Collapsed {
+				sz.h = headerHeight
+			} else if n.Height > 0 {
+				sz.h = n.Height
+			}
+		}
+		g.sizes = append(g.sizes, sz)
+	}
+
+	g.parents = make([][]int, len(g.names))
+	g.adj = make([][]int, len(g.names))
+	directed := make(map[[2]int]bool)
+	undirected := make(map[[2]int]bool)
+	for _, rel := range schema.Relations {
+		src, ok1 := index[rel.SourceTable]
+		dst, ok2 := index[rel.TargetTable]
+		if !ok1 || !ok2 || src == dst {
+			continue
+		}
+		if !directed[[2]int{src, dst}] {
+			directed[[2]int{src, dst}] = true
+			g.parents[src] = append(g.parents[src], dst)
+		}
+		a, b := src, dst
+		if a > b {
+			a, b = b, a
+		}
+		if !undirected[[2]int{a, b}] {
+			undirected[[2]int{a, b}] = true
+			g.adj[a] = append(g.adj[a], b)
+			g.adj[b] = append(g.adj[b], a)
+		}
+	}
+	return g
+}
+
+// components returns the connected components, largest first, each in
+// breadth-first order starting from its most connected table.
+func (g *graph) components() [][]int {
+	visited := make([]bool, len(g.names))
+	var comps [][]int
+	for start := range g.names {
+		if visited[start] {
+			continue
+		}
+		var members []int
+		queue := []int{start}
+		visited[start] = true
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			members = append(members, n)
+			for _, m := range g.adj[n] {
+				if !visited[m] {
+					visited[m] = true
+					queue = append(queue, m)
+				}
+			}
+		}
+		comps = append(comps, g.bfsFromHub(members))
+	}
+	sort.SliceStable(comps, func(i, j int) bool { return len(comps[i]) > len(comps[j]) })
+	return comps
+}
+
+func (g *graph) bfsFromHub(members []int) []int {
+	hub := members[0]
+	for _, m := range members {
+		if len(g.adj[m]) > len(g.adj[hub]) {
+			hub = m
+		}
+	}
+	visited := map[int]bool{hub: true}
+	order := []int{hub}
+	for i := 0; i < len(order); i++ {
+		for _, m := range g.adj[order[i]] {
+			if !visited[m] {
+				visited[m] = true
+				order = append(order, m)
+			}
+		}
+	}
+	return order
+}
+
+// computeLayout returns table positions for the schema using the given
+// algorithm, and the size assumed for each table. Sizes of nodes already in
+// current are taken into account.
+func computeLayout(schema *domain.DatabaseSchema, current *domain.Layout, algorithm string) (map[string]domain.NodeLayout, map[string]size, error) {
+	if current == nil {
+		current = domain.NewLayout()
+	}
+	g := newGraph(schema, current)
+
+	var pos []domain.Point
+	switch algorithm {
+	case AlgorithmLayered, "":
+		pos = layered(g)
+	case AlgorithmForce:
+		pos = force(g)
+	case AlgorithmGrid:
+		pos = grid(g)
+	default:
+		return nil, nil, fmt.Errorf("unknown layout algorithm %q (use layered, force or grid): %w", algorithm, domain.ErrInvalidInput)
+	}
+
+	nodes := make(map[string]domain.NodeLayout, len(g.names))
+	sizes := make(map[string]size, len(g.names))
+	for i, name := range g.names {
+		n := current.Nodes[name]
+		n.X = math.Round(pos[i].X)
+		n.Y = math.Round(pos[i].Y)
+		nodes[name] = n
+		sizes[name] = g.sizes[i]
+	}
+	return nodes, sizes, nil
+}
+
+// grid places tables row by row, keeping connected tables next to each other
+func grid(g *graph) []domain.Point {
+	pos := make([]domain.Point, len(g.names))
+	var order []int
+	for _, comp := range g.components() {
+		order = append(order, comp...)
+	}
+	if len(order) == 0 {
+		return pos
+	}
+
+	cols := int(math.Ceil(math.Sqrt(float64(len(order)))))
+	cellW := 0.0
+	for _, n := range order {
+		cellW = math.Max(cellW, g.sizes[n].w)
+	}
+	y := canvasPadding
+	for start := 0; start < len(order); start += cols {
+		end := start + cols
+		if end > len(order) {
+			end = len(order)
+		}
+		rowH := 0.0
+		for i, n := range order[start:end] {
+			pos[n] = domain.Point{X: canvasPadding + float64(i)*(cellW+gapX), Y: y}
+			rowH = math.Max(rowH, g.sizes[n].h)
+		}
+		y += rowH + gapY
+	}
+	return pos
+}
+
+// layered is a simplified Sugiyama layout: referenced (parent) tables are
+// placed above the tables pointing at them, layers are reordered with the
+// barycenter heuristic to reduce crossings, and every connected component
+// gets its own block. Unrelated tables are packed in a grid underneath.
+func layered(g *graph) []domain.Point {
+	pos := make([]domain.Point, len(g.names))
+
+	var blocks [][]int
+	var singles []int
+	for _, comp := range g.components() {
+		if len(comp) == 1 {
+			singles = append(singles, comp[0])
+			continue
+		}
+		blocks = append(blocks, comp)
+	}
+
+	x, y, rowH := canvasPadding, canvasPadding, 0.0
+	for _, comp := range blocks {
+		local, w, h := layeredComponent(g, comp)
+		if x > canvasPadding && x+w > maxRowWidth {
+			x = canvasPadding
+			y += rowH + gapY
+			rowH = 0
+		}
+		for n, p := range local {
+			pos[n] = domain.Point{X: x + p.X, Y: y + p.Y}
+		}
+		x += w + gapX*2
+		rowH = math.Max(rowH, h)
+	}
+	if len(blocks) > 0 {
+		y += rowH + gapY
+	}
+
+	if len(singles) > 0 {
+		sub := &graph{names: make([]string, len(singles)), sizes: make([]size, len(singles)), adj: make([][]int, len(singles))}
+		for i, n := range singles {
+			sub.names[i] = g.names[n]
+			sub.sizes[i] = g.sizes[n]
+		}
+		for i, p := range grid(sub) {
+			pos[singles[i]] = domain.Point{X: p.X, Y: y + p.Y - canvasPadding}
+		}
+	}
+	return pos
+}
+
+// layeredComponent lays out one connected component with its top-left at
+// 0,0 and returns the positions plus the block width and height.
+func layeredComponent(g *graph, comp []int) (map[int]domain.Point, float64, float64) {
+	inComp := make(map[int]bool, len(comp))
+	for _, n := range comp {
+		inComp[n] = true
+	}
+
+	// Edges parent -> child with cycles broken by dropping back edges.
+	children := make(map[int][]int)
+	state := make(map[int]int) // 0 unvisited, 1 on stack, 2 done
+	var visit func(n int)
+	visit = func(n int) {
+		state[n] = 1
+		for _, c := range g.adj[n] {
+			if !inComp[c] || !references(g, c, n) {
+				continue
+			}
+			if state[c] == 1 {
+				continue
+			}
+			children[n] = append(children[n], c)
+			if state[c] == 0 {
+				visit(c)
+			}
+		}
+		state[n] = 2
+	}
+	for _, n := range comp {
+		if state[n] == 0 {
+			visit(n)
+		}
+	}
+
+	// Longest-path layering via Kahn's algorithm.
+	indeg := make(map[int]int)
+	for _, n := range comp {
+		for _, c := range children[n] {
+			indeg[c]++
+		}
+	}
+	layer := make(map[int]int)
+	var queue []int
+	for _, n := range comp {
+		if indeg[n] == 0 {
+			queue = append(queue, n)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, c := range children[n] {
+			if layer[n]+1 > layer[c] {
+				layer[c] = layer[n] + 1
+			}
+			indeg[c]--
+			if indeg[c] == 0 {
+				queue = append(queue, c)
+			}
+		}
+	}
+
+	depth := 0
+	for _, n := range comp {
+		if layer[n] > depth {
+			depth = layer[n]
+		}
+	}
+	layers := make([][]int, depth+1)
+	for _, n := range comp {
+		layers[layer[n]] = append(layers[layer[n]], n)
+	}
+
+	orderLayers(g, layers)
+
+	// Assign coordinates, centring each layer on the widest one.
+	widths := make([]float64, len(layers))
+	maxW := 0.0
+	for i, l := range layers {
+		for j, n := range l {
+			if j > 0 {
+				widths[i] += gapX
+			}
+			widths[i] += g.sizes[n].w
+		}
+		maxW = math.Max(maxW, widths[i])
+	}
+	pos := make(map[int]domain.Point, len(comp))
+	y := 0.0
+	for i, l := range layers {
+		x := (maxW - widths[i]) / 2
+		layerH := 0.0
+		for _, n := range l {
+			pos[n] = domain.Point{X: x, Y: y}
+			x += g.sizes[n].w + gapX
+			layerH = math.Max(layerH, g.sizes[n].h)
+		}
+		y += layerH + gapY
+	}
+	return pos, maxW, y - gapY
+}
+
+func references(g *graph, child, parent int) bool {
+	for _, p := range g.parents[child] {
+		if p == parent {
+			return true
+		}
+	}
+	return false
+}
+
+// orderLayers runs alternating down/up barycenter sweeps and keeps the
+// ordering with the fewest crossings between adjacent layers.
+func orderLayers(g *graph, layers [][]int) {
+	const sweeps = 12
+
+	best := cloneLayers(layers)
+	bestCrossings := countCrossings(g, layers)
+	for s := 0; s < sweeps && bestCrossings > 0; s++ {
+		if s%2 == 0 {
+			for i := 1; i < len(layers); i++ {
+				sortByBarycenter(g, layers[i], layers[i-1])
+			}
+		} else {
+			for i := len(layers) - 2; i >= 0; i-- {
+				sortByBarycenter(g, layers[i], layers[i+1])
+			}
+		}
+		if c := countCrossings(g, layers); c < bestCrossings {
+			bestCrossings = c
+			best = cloneLayers(layers)
+		}
+	}
+	copy(layers, best)
+}
+
+func sortByBarycenter(g *graph, layer, fixed []int) {
+	index := make(map[int]int, len(fixed))
+	for i, n := range fixed {
+		index[n] = i
+	}
+	bary := make(map[int]float64, len(layer))
+	for i, n := range layer {
+		sum, count := 0.0, 0
+		for _, m := range g.adj[n] {
+			if j, ok := index[m]; ok {
+				sum += float64(j)
+				count++
+			}
+		}
+		if count == 0 {
+			// keep nodes without neighbours roughly where they are
+			bary[n] = float64(i) * float64(len(fixed)) / float64(len(layer))
+		} else {
+			bary[n] = sum / float64(count)
+		}
+	}
+	sort.SliceStable(layer, func(i, j int) bool { return bary[layer[i]] < bary[layer[j]] })
+}
+
+func countCrossings(g *graph, layers [][]int) int {
+	total := 0
+	for i := 0; i+1 < len(layers); i++ {
+		upper := make(map[int]int, len(layers[i]))
+		for j, n := range layers[i] {
+			upper[n] = j
+		}
+		type edge struct{ a, b int }
+		var edges []edge
+		for b, n := range layers[i+1] {
+			for _, m := range g.adj[n] {
+				if a, ok := upper[m]; ok {
+					edges = append(edges, edge{a, b})
+				}
+			}
+		}
+		for x := 0; x < len(edges); x++ {
+			for y := x + 1; y < len(edges); y++ {
+				if (edges[x].a-edges[y].a)*(edges[x].b-edges[y].b) < 0 {
+					total++
+				}
+			}
+		}
+	}
+	return total
+}
+
+func cloneLayers(layers [][]int) [][]int {
+	out := make([][]int, len(layers))
+	for i, l := range layers {
+		out[i] = append([]int(nil), l...)
+	}
+	return out
+}
+
+// force runs a Fruchterman-Reingold simulation from a deterministic
+// circular start, so related tables are pulled together and clusters form.
+func force(g *graph) []domain.Point {
+	n := len(g.names)
+	pos := make([]domain.Point, n)
+	if n == 0 {
+		return pos
+	}
+
+	const (
+		iterations = 300
+		// gravity pulls each table toward the origin in proportion to its
+		// distance. Repulsion (k²/d) still wins at close range, so tables
+		// keep about k apart, but the linear pull balances the summed
+		// repulsion at roughly sqrt(n)·k across. Weaker values such as 0.1
+		// spread a 25-table diagram three times wider without spacing
+		// neighbours any better, and let unrelated tables drift off.
+		gravity = 1.0
+	)
+	k := nodeWidth + 2*gapX
+	radius := k * math.Sqrt(float64(n)) / 2
+	for i := range pos {
+		angle := 2 * math.Pi * float64(i) / float64(n)
+		pos[i] = domain.Point{X: radius * math.Cos(angle), Y: radius * math.Sin(angle)}
+	}
+
+	temp := radius / 4
+	disp := make([]domain.Point, n)
+	for it := 0; it < iterations; it++ {
+		for i := range disp {
+			disp[i] = domain.Point{}
+		}
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				dx, dy := pos[i].X-pos[j].X, pos[i].Y-pos[j].Y
+				d := math.Max(math.Hypot(dx, dy), 1)
+				f := k * k / d
+				disp[i].X += dx / d * f
+				disp[i].Y += dy / d * f
+				disp[j].X -= dx / d * f
+				disp[j].Y -= dy / d * f
+			}
+		}
+		for i := 0; i < n; i++ {
+			for _, j := range g.adj[i] {
+				if j < i {
+					continue
+				}
+				dx, dy := pos[i].X-pos[j].X, pos[i].Y-pos[j].Y
+				d := math.Max(math.Hypot(dx, dy), 1)
+				f := d * d / k
+				disp[i].X -= dx / d * f
+				disp[i].Y -= dy / d * f
+				disp[j].X += dx / d * f
+				disp[j].Y += dy / d * f
+			}
+			// gravity keeps the diagram compact and disconnected tables near
+			// the rest
+			disp[i].X -= pos[i].X * gravity
+			disp[i].Y -= pos[i].Y * gravity
+		}
+		for i := range pos {
+			d := math.Max(math.Hypot(disp[i].X, disp[i].Y), 1)
+			step := math.Min(d, temp)
+			pos[i].X += disp[i].X / d * step
+			pos[i].Y += disp[i].Y / d * step
+		}
+		temp = math.Max(temp*0.98, 1)
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	for _, p := range pos {
+		minX = math.Min(minX, p.X)
+		minY = math.Min(minY, p.Y)
+	}
+	for i := range pos {
+		pos[i].X += canvasPadding - minX
+		pos[i].Y += canvasPadding - minY
+	}
+	return pos
+}
@@ -3,20 +3,131 @@ package layout
 import (
 	"backend/internal/domain"
 	"context"
+	"errors"
+	"log"
+	"math"
 )
 
 type layoutService struct {
-	repo domain.SchemaRepository
+	repo  domain.SchemaRepository
+	store domain.LayoutRepository
+	views domain.ViewService
 }
 
-func NewLayoutService(repo domain.SchemaRepository) domain.LayoutService {
-	return &layoutService{repo: repo}
+func NewLayoutService(repo domain.SchemaRepository, store domain.LayoutRepository, views domain.ViewService) domain.LayoutService {
+	return &layoutService{repo: repo, store: store, views: views}
 }
 
-func (s *layoutService) Save(ctx context.Context, layouts map[string]interface{}) error {
-	return s.repo.SaveLayout(ctx, layouts)
+func (s *layoutService) Save(ctx context.Context, key domain.LayoutKey, layout *domain.Layout) error {
+	if err := layout.Validate(); err != nil {
+		return err
+	}
+	layout.Normalize()
+	return s.store.Save(ctx, key, layout)
 }
 
-func (s *layoutService) Get(ctx context.Context) (map[string]interface{}, error) {
-	return s.repo.GetLayout(ctx)
+func (s *layoutService) Get(ctx context.Context, key domain.LayoutKey) (*domain.Layout, error) {
+	layout, found, err := s.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return layout, nil
+	}
+	if key.Name != domain.DefaultLayoutName {
+		return domain.NewLayout(), nil
+	}
+
+	// Nothing stored locally yet: import the _layout table older versions
+	// wrote into the user's database, if there is one.
+	legacy, err := s.repo.GetLegacyLayout(ctx, key.Database)
+	if err != nil {
+		log.Printf("Warning: could not read legacy layout for %s: %v", key.Database, err)
+		return domain.NewLayout(), nil
+	}
+	if legacy == nil {
+		return domain.NewLayout(), nil
+	}
+	if err := s.store.Save(ctx, key, legacy); err != nil {
+		return nil, err
+	}
+	log.Printf("Migrated %d table positions from %s._layout", len(legacy.Nodes), key.Database)
+	return legacy, nil
+}
+
+func (s *layoutService) Delete(ctx context.Context, key domain.LayoutKey) error {
+	return s.store.Delete(ctx, key)
+}
+
+func (s *layoutService) List(ctx context.Context, connection, database string) ([]string, error) {
+	return s.store.List(ctx, connection, database)
+}
+
+// AutoLayout positions every table of the database with the given algorithm.
+// Sizes, colors and collapsed state are kept; manual edge routing is reset
+// and group bounds are recomputed around their members.
+func (s *layoutService) AutoLayout(ctx context.Context, key domain.LayoutKey, algorithm string, save bool) (*domain.Layout, error) {
+	schema, err := s.schemaFor(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	layout, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, sizes, err := computeLayout(schema, layout, algorithm)
+	if err != nil {
+		return nil, err
+	}
+	layout.Nodes = nodes
+	layout.Edges = []domain.EdgeLayout{}
+	for i := range layout.Groups {
+		fitGroup(&layout.Groups[i], nodes, sizes)
+	}
+
+	if save {
+		if err := s.store.Save(ctx, key, layout); err != nil {
+			return nil, err
+		}
+	}
+	return layout, nil
+}
+
+// schemaFor returns the tables a layout covers: the view's subset when the
+// layout belongs to a diagram view, the whole database otherwise.
+func (s *layoutService) schemaFor(ctx context.Context, key domain.LayoutKey) (*domain.DatabaseSchema, error) {
+	if key.Name != domain.DefaultLayoutName {
+		schema, err := s.views.Schema(ctx, key.Connection, key.Database, key.Name)
+		if !errors.Is(err, domain.ErrNotFound) {
+			return schema, err
+		}
+	}
+	return s.repo.GetFullSchema(ctx, key.Database)
+}
+
+// fitGroup resizes a group box so it encloses its member tables, using the
+// sizes the layout placed them with
+func fitGroup(g *domain.GroupLayout, nodes map[string]domain.NodeLayout, sizes map[string]size) {
+	const padding = 30.0
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, t := range g.Tables {
+		n, ok := nodes[t]
+		if !ok {
+			continue
+		}
+		sz := sizes[t]
+		minX, minY = math.Min(minX, n.X), math.Min(minY, n.Y)
+		maxX, maxY = math.Max(maxX, n.X+sz.w), math.Max(maxY, n.Y+sz.h)
+	}
+	if math.IsInf(minX, 1) {
+		return
+	}
+	g.Bounds = domain.Bounds{
+		X:      minX - padding,
+		Y:      minY - padding,
+		Width:  maxX - minX + 2*padding,
+		Height: maxY - minY + 2*padding,
+	}
 }
@@ -0,0 +1,179 @@
+package query
+
+import (
+	"backend/internal/app/database"
+	"backend/internal/domain"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 500
+	// maxHistoryText caps the query text kept per history entry
+	maxHistoryText = 64 << 10
+)
+
+type queryService struct {
+	store domain.QueryRepository
+	db    domain.DatabaseService // runs saved queries
+}
+
+func NewQueryService(store domain.QueryRepository, db domain.DatabaseService) domain.QueryService {
+	return &queryService{store: store, db: db}
+}
+
+func (s *queryService) Record(ctx context.Context, connection string, req domain.ConsoleRequest, result *domain.QueryResult, err error) error {
+	text := req.Query
+	if len(text) > maxHistoryText {
+		text = strings.ToValidUTF8(text[:maxHistoryText], "")
+	}
+	entry := domain.HistoryEntry{
+		ID:         newID(),
+		Database:   req.Database,
+		Query:      text,
+		Success:    err == nil,
+		ExecutedAt: time.Now().UTC(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if result != nil {
+		entry.DurationMs = result.DurationMs
+		for _, r := range result.Results {
+			entry.RowCount += r.RowsAffected
+			if r.Error != "" && entry.Success {
+				entry.Success, entry.Error = false, r.Error
+			}
+		}
+	}
+	return s.store.AddHistory(ctx, connection, entry)
+}
+
+func (s *queryService) History(ctx context.Context, connection string, q domain.HistoryQuery) (*domain.HistoryPage, error) {
+	switch q.Status {
+	case "", "ok", "error":
+	default:
+		return nil, fmt.Errorf("status must be ok or error: %w", domain.ErrInvalidInput)
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultHistoryLimit
+	}
+	if q.Limit > maxHistoryLimit {
+		q.Limit = maxHistoryLimit
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	return s.store.SearchHistory(ctx, connection, q)
+}
+
+func (s *queryService) ClearHistory(ctx context.Context, connection string) error {
+	return s.store.ClearHistory(ctx, connection)
+}
+
+func (s *queryService) ListSaved(ctx context.Context, connection, tag, search string) ([]domain.SavedQuery, error) {
+	all, err := s.store.ListSaved(ctx, connection)
+	if err != nil {
+		return nil, err
+	}
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	search = strings.ToLower(search)
+	queries := []domain.SavedQuery{}
+	for _, q := range all {
+		if tag != "" && !hasTag(q, tag) {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(q.Name+"\n"+q.Description+"\n"+q.Query), search) {
+			continue
+		}
+		queries = append(queries, q)
+	}
+	return queries, nil
+}
+
+func (s *queryService) GetSaved(ctx context.Context, connection, name string) (*domain.SavedQuery, error) {
+	q, found, err := s.store.GetSaved(ctx, connection, name)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("saved query %s: %w", name, domain.ErrNotFound)
+	}
+	return q, nil
+}
+
+// Save creates or replaces a saved query, keeping its creation time
+func (s *queryService) Save(ctx context.Context, connection string, q domain.SavedQuery) (*domain.SavedQuery, error) {
+	if err := q.Validate(); err != nil {
+		return nil, fmt.Errorf("%v: %w", err, domain.ErrInvalidInput)
+	}
+	if err := database.CheckParameters(q.Query, q.Parameters); err != nil {
+		return nil, fmt.Errorf("query %s: %w", q.Name, err)
+	}
+	existing, found, err := s.store.GetSaved(ctx, connection, q.Name)
+	if err != nil {
+		return nil, err
+	}
+	q.UpdatedAt = time.Now().UTC()
+	q.CreatedAt = q.UpdatedAt
+	if found {
+		q.CreatedAt = existing.CreatedAt
+	}
+	if err := s.store.SaveQuery(ctx, connection, q); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+func (s *queryService) DeleteSaved(ctx context.Context, connection, name string) error {
+	if _, err := s.GetSaved(ctx, connection, name); err != nil {
+		return err
+	}
+	return s.store.DeleteSaved(ctx, connection, name)
+}
+
+// Run executes a saved query in its database. Parameter values are bound
+// by the driver, never spliced into the SQL text.
+func (s *queryService) Run(ctx context.Context, connection, name string, params map[string]interface{}, maxRows int) (*domain.QueryResult, error) {
+	q, err := s.GetSaved(ctx, connection, name)
+	if err != nil {
+		return nil, err
+	}
+	req := domain.ConsoleRequest{
+		Query:      q.Query,
+		Database:   q.Database,
+		MaxRows:    maxRows,
+		Parameters: q.Parameters,
+		Params:     params,
+	}
+	if req.Params == nil {
+		// bind defaults and NULLs even when no values are sent
+		req.Params = map[string]interface{}{}
+	}
+	result, err := s.db.Execute(ctx, req)
+	if herr := s.Record(ctx, connection, req, result, err); herr != nil {
+		log.Printf("Recording query history failed: %v", herr)
+	}
+	return result, err
+}
+
+func hasTag(q domain.SavedQuery, tag string) bool {
+	for _, t := range q.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
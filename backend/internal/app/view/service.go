@@ -0,0 +1,128 @@
+package view
+
+import (
+	"backend/internal/domain"
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+type viewService struct {
+	repo    domain.SchemaRepository
+	store   domain.ViewRepository
+	layouts domain.LayoutRepository
+}
+
+func NewViewService(repo domain.SchemaRepository, store domain.ViewRepository, layouts domain.LayoutRepository) domain.ViewService {
+	return &viewService{repo: repo, store: store, layouts: layouts}
+}
+
+func (s *viewService) List(ctx context.Context, connection, database string) ([]domain.DiagramView, error) {
+	return s.store.List(ctx, connection, database)
+}
+
+func (s *viewService) Save(ctx context.Context, connection, database string, view domain.DiagramView) error {
+	if err := view.Validate(); err != nil {
+		return err
+	}
+	return s.store.Save(ctx, connection, database, view)
+}
+
+// Delete removes a view together with its layout (the layout of the same name)
+func (s *viewService) Delete(ctx context.Context, connection, database, name string) error {
+	if err := s.store.Delete(ctx, connection, database, name); err != nil {
+		return err
+	}
+	if name == domain.DefaultLayoutName {
+		// not a view name; that layout belongs to the full diagram
+		return nil
+	}
+	return s.layouts.Delete(ctx, domain.LayoutKey{Connection: connection, Database: database, Name: name})
+}
+
+func (s *viewService) Schema(ctx context.Context, connection, database, name string) (*domain.DatabaseSchema, error) {
+	view, found, err := s.store.Get(ctx, connection, database, name)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("view %s: %w", name, domain.ErrNotFound)
+	}
+	schema, err := s.repo.GetFullSchema(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	return Apply(view, schema), nil
+}
+
+// Apply returns the part of schema selected by the view
+func Apply(view *domain.DiagramView, schema *domain.DatabaseSchema) *domain.DatabaseSchema {
+	selected := make(map[string]bool)
+
+	byName := make(map[string]string, len(schema.Tables))
+	for _, t := range schema.Tables {
+		byName[strings.ToLower(t.Name)] = t.Name
+	}
+	for _, t := range view.Tables {
+		if name, ok := byName[strings.ToLower(t)]; ok {
+			selected[name] = true
+		}
+	}
+	for _, p := range view.Patterns {
+		p = strings.ToLower(p)
+		for _, t := range schema.Tables {
+			if ok, _ := path.Match(p, strings.ToLower(t.Name)); ok {
+				selected[t.Name] = true
+			}
+		}
+	}
+	if view.Around != nil {
+		if start, ok := byName[strings.ToLower(view.Around.Table)]; ok {
+			for name := range neighborhood(schema.Relations, start, view.Around.Hops) {
+				selected[name] = true
+			}
+		}
+	}
+
+	out := &domain.DatabaseSchema{
+		Tables:    []domain.TableSchema{},
+		Relations: []domain.RelationSchema{},
+	}
+	for _, t := range schema.Tables {
+		if selected[t.Name] {
+			out.Tables = append(out.Tables, t)
+		}
+	}
+	for _, r := range schema.Relations {
+		if selected[r.SourceTable] && selected[r.TargetTable] {
+			out.Relations = append(out.Relations, r)
+		}
+	}
+	return out
+}
+
+// neighborhood walks relations in both directions up to hops steps from start
+func neighborhood(relations []domain.RelationSchema, start string, hops int) map[string]bool {
+	adj := make(map[string][]string)
+	for _, r := range relations {
+		adj[r.SourceTable] = append(adj[r.SourceTable], r.TargetTable)
+		adj[r.TargetTable] = append(adj[r.TargetTable], r.SourceTable)
+	}
+
+	seen := map[string]bool{start: true}
+	frontier := []string{start}
+	for i := 0; i < hops && len(frontier) > 0; i++ {
+		var next []string
+		for _, t := range frontier {
+			for _, n := range adj[t] {
+				if !seen[n] {
+					seen[n] = true
+					next = append(next, n)
+				}
+			}
+		}
+		frontier = next
+	}
+	return seen
+}
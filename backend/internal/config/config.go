@@ -2,10 +2,13 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"strconv"
 	"sync"
+	"time"
 )
 
 type ConnectionConfig struct {
@@ -14,18 +17,70 @@ type ConnectionConfig struct {
 	Port     int    `json:"port"`
 	User     string `json:"user"`
 	Password string `json:"password"`
-	Database string `json:"database,omitempty"`
+	// PasswordEnv or PasswordFile name where the password is read from
+	// instead of storing it
+	PasswordEnv  string `json:"password_env,omitempty"`
+	PasswordFile string `json:"password_file,omitempty"`
+	Database     string `json:"database,omitempty"`
+	// undecrypted keeps a stored password that could not be decrypted
+	// (wrong master key) so that saving does not lose it
+	undecrypted string
+	// ReadOnly refuses every change to the database through this
+	// connection; the console runs in read-only transactions
+	ReadOnly bool `json:"read_only,omitempty"`
+	// SSLMode is one of the SSL* modes; SSLCA, SSLCert and SSLKey are PEM
+	// files (CA to verify the server, client certificate and key)
+	SSLMode string `json:"ssl_mode,omitempty"`
+	SSLCA   string `json:"ssl_ca,omitempty"`
+	SSLCert string `json:"ssl_cert,omitempty"`
+	SSLKey  string `json:"ssl_key,omitempty"`
+	// SSH, when set, reaches Host:Port through a tunnel over this server
+	SSH *SSHConfig `json:"ssh,omitempty"`
+}
+
+// SSHConfig is an SSH bastion that connections are tunneled through. It
+// authenticates with the private key at KeyPath and/or the ssh-agent at
+// SSH_AUTH_SOCK. The bastion's host key is checked against KnownHosts
+// (~/.ssh/known_hosts by default).
+type SSHConfig struct {
+	Host       string `json:"host"`
+	Port       int    `json:"port,omitempty"`
+	User       string `json:"user"`
+	KeyPath    string `json:"key_path,omitempty"`
+	Passphrase string `json:"passphrase,omitempty"`
+	// PassphraseEncrypted is how Passphrase is stored in connections.json
+	PassphraseEncrypted string `json:"passphrase_encrypted,omitempty"`
+	UseAgent            bool   `json:"use_agent,omitempty"`
+	KnownHosts          string `json:"known_hosts,omitempty"`
 }
 
 type Config struct {
 	ServerPort string
 	DSN        string
+	// BodyLimit is the largest request body in bytes (uploads for import)
+	BodyLimit int
+	// QueryTimeout limits each SQL console statement; 0 disables it
+	QueryTimeout time.Duration
+	// ReadOnly is set when the active connection is read_only
+	ReadOnly bool
+	// SSH is the tunnel of the active connection, if any
+	SSH *SSHConfig
 }
 
-const connectionsFile = "connections.json"
+const (
+	connectionsFile = "connections.json"
+	defaultDataDir  = "data"
+	defaultUploadMB = 64
+	// defaultQueryTimeout is in seconds, see QUERY_TIMEOUT_SECONDS
+	defaultQueryTimeout = 300
+)
 
 var (
 	mu sync.Mutex
+
+	// activeConnection is the name of the saved connection the server is
+	// currently using. It is empty when the DSN comes from DB_DSN.
+	activeConnection string
 )
 
 func getConfigPath() string {
@@ -37,6 +92,33 @@ func getLocalConfigPath() string {
 	return connectionsFile
 }
 
+// DataDir returns the directory for local metadata (layouts, history, ...).
+// It can be overridden with DRAWDB_DATA_DIR.
+func DataDir() string {
+	if dir := os.Getenv("DRAWDB_DATA_DIR"); dir != "" {
+		return dir
+	}
+	return defaultDataDir
+}
+
+// SetActiveConnectionName records which saved connection is in use
+func SetActiveConnectionName(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	activeConnection = name
+}
+
+// ActiveConnectionName returns the saved connection in use, or "default"
+// when the server was started from a raw DSN.
+func ActiveConnectionName() string {
+	mu.Lock()
+	defer mu.Unlock()
+	if activeConnection == "" {
+		return "default"
+	}
+	return activeConnection
+}
+
 // LoadConfig loads server config with sensible defaults
 func LoadConfig() *Config {
 	dsn := "root:root@tcp(127.0.0.1:3306)/?charset=utf8mb4&parseTime=True&loc=Local"
@@ -48,34 +130,84 @@ func LoadConfig() *Config {
 	if envPort := os.Getenv("PORT"); envPort != "" {
 		port = envPort
 	}
+	uploadMB := defaultUploadMB
+	if n, err := strconv.Atoi(os.Getenv("MAX_UPLOAD_MB")); err == nil && n > 0 {
+		uploadMB = n
+	}
+	timeout := defaultQueryTimeout
+	if n, err := strconv.Atoi(os.Getenv("QUERY_TIMEOUT_SECONDS")); err == nil && n >= 0 {
+		timeout = n
+	}
 
 	// Try to load from connections.json
 	conn, err := GetActiveConnection()
+	readOnly := false
+	var ssh *SSHConfig
 	if err == nil && conn != nil {
-		dsn = BuildDSN(conn)
+		if connDSN, err := BuildDSN(conn); err != nil {
+			log.Printf("Warning: connection %s: %v", conn.Name, err)
+		} else {
+			dsn = connDSN
+			readOnly = conn.ReadOnly
+			ssh = conn.SSH
+			SetActiveConnectionName(conn.Name)
+		}
 	}
 
 	return &Config{
-		ServerPort: port,
-		DSN:        dsn,
+		ServerPort:   port,
+		DSN:          dsn,
+		BodyLimit:    uploadMB << 20,
+		QueryTimeout: time.Duration(timeout) * time.Second,
+		ReadOnly:     readOnly,
+		SSH:          ssh,
 	}
 }
 
-// BuildDSN constructs a MySQL DSN from a ConnectionConfig
-func BuildDSN(c *ConnectionConfig) string {
+// BuildDSN constructs a MySQL DSN from a ConnectionConfig. It fails when
+// the password or the TLS settings cannot be loaded rather than connecting
+// without them.
+func BuildDSN(c *ConnectionConfig) (string, error) {
 	port := strconv.Itoa(c.Port)
 	if c.Port == 0 {
 		port = "3306"
 	}
-	dsn := c.User + ":" + c.Password + "@tcp(" + c.Host + ":" + port + ")/"
+	password, err := c.ResolvePassword()
+	if err != nil {
+		return "", err
+	}
+	dsn := c.User + ":" + password + "@tcp(" + c.Host + ":" + port + ")/"
 	if c.Database != "" {
 		dsn += c.Database
 	}
 	dsn += "?charset=utf8mb4&parseTime=True&loc=Local"
-	return dsn
+	params, err := tlsParams(c)
+	if err != nil {
+		return "", err
+	}
+	return dsn + params, nil
 }
 
-// GetConnections reads all saved connections
+// KeepPassphrase copies the saved passphrase of the same key into an
+// edit that did not send one
+func (s *SSHConfig) KeepPassphrase(saved *SSHConfig) {
+	if s == nil || saved == nil || s.Passphrase != "" || s.KeyPath != saved.KeyPath {
+		return
+	}
+	s.Passphrase, s.PassphraseEncrypted = saved.Passphrase, saved.PassphraseEncrypted
+}
+
+// storedConnection is a connection as written to connections.json: the
+// password only encrypted. Password is still read from files written
+// before encryption, which are migrated on load.
+type storedConnection struct {
+	ConnectionConfig
+	Password          string `json:"password,omitempty"`
+	PasswordEncrypted string `json:"password_encrypted,omitempty"`
+}
+
+// GetConnections reads all saved connections with their passwords
+// decrypted. Plaintext passwords are encrypted in place.
 func GetConnections() ([]ConnectionConfig, error) {
 	mu.Lock()
 	defer mu.Unlock()
@@ -88,11 +220,46 @@ func GetConnections() ([]ConnectionConfig, error) {
 		return nil, err
 	}
 
-	var conns []ConnectionConfig
-	if err := json.Unmarshal(data, &conns); err != nil {
+	var stored []storedConnection
+	if err := json.Unmarshal(data, &stored); err != nil {
 		// If JSON is corrupted, return empty list instead of crashing
 		return []ConnectionConfig{}, nil
 	}
+	conns := make([]ConnectionConfig, len(stored))
+	migrate := false
+	for i, sc := range stored {
+		conns[i] = sc.ConnectionConfig
+		conns[i].Password = sc.Password
+		if sc.Password != "" {
+			migrate = true
+		}
+		if sc.PasswordEncrypted != "" {
+			if conns[i].Password, err = decryptSecret(sc.PasswordEncrypted); err != nil {
+				log.Printf("Warning: password of connection %s: %v", sc.Name, err)
+				conns[i].undecrypted = sc.PasswordEncrypted
+			}
+		}
+		if ssh := sc.SSH; ssh != nil {
+			if ssh.Passphrase != "" {
+				migrate = true
+			}
+			if ssh.PassphraseEncrypted != "" {
+				// on failure PassphraseEncrypted is kept and saved again
+				if p, err := decryptSecret(ssh.PassphraseEncrypted); err != nil {
+					log.Printf("Warning: SSH passphrase of connection %s: %v", sc.Name, err)
+				} else {
+					ssh.Passphrase, ssh.PassphraseEncrypted = p, ""
+				}
+			}
+		}
+	}
+	if migrate {
+		if err := writeConnections(conns); err != nil {
+			log.Printf("Warning: could not encrypt saved passwords: %v", err)
+		} else {
+			log.Println("Encrypted plaintext passwords in " + getLocalConfigPath())
+		}
+	}
 	return conns, nil
 }
 
@@ -100,12 +267,56 @@ func GetConnections() ([]ConnectionConfig, error) {
 func SaveConnections(conns []ConnectionConfig) error {
 	mu.Lock()
 	defer mu.Unlock()
+	return writeConnections(conns)
+}
+
+// writeConnections encrypts the passwords and replaces the file (0600)
+// atomically. Passwords that come from PasswordEnv or PasswordFile are
+// never written.
+func writeConnections(conns []ConnectionConfig) error {
+	stored := make([]storedConnection, len(conns))
+	for i, c := range conns {
+		stored[i].ConnectionConfig = c
+		stored[i].ConnectionConfig.Password = ""
+		if c.Password == "" && !c.hasSecretRef() {
+			stored[i].PasswordEncrypted = c.undecrypted
+		}
+		if c.Password == "" || c.hasSecretRef() {
+			continue
+		}
+		enc, err := encryptSecret(c.Password)
+		if err != nil {
+			return fmt.Errorf("encrypting password of %s: %v", c.Name, err)
+		}
+		stored[i].PasswordEncrypted = enc
+	}
+	for i, c := range conns {
+		if c.SSH == nil || c.SSH.Passphrase == "" {
+			continue
+		}
+		ssh := *c.SSH
+		enc, err := encryptSecret(ssh.Passphrase)
+		if err != nil {
+			return fmt.Errorf("encrypting SSH passphrase of %s: %v", c.Name, err)
+		}
+		ssh.Passphrase, ssh.PassphraseEncrypted = "", enc
+		stored[i].SSH = &ssh
+	}
 
-	data, err := json.MarshalIndent(conns, "", "  ")
+	data, err := json.MarshalIndent(stored, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(getLocalConfigPath(), data, 0644)
+	path := getLocalConfigPath()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := os.Chmod(tmp, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
 }
 
 // GetActiveConnection returns the first connection (or nil if none)
@@ -128,6 +339,12 @@ func AddConnection(conn ConnectionConfig) error {
 	found := false
 	for i, c := range conns {
 		if c.Name == conn.Name {
+			// an edit without any password keeps the saved one
+			if conn.Password == "" && !conn.hasSecretRef() {
+				conn.Password, conn.PasswordEnv, conn.PasswordFile = c.Password, c.PasswordEnv, c.PasswordFile
+				conn.undecrypted = c.undecrypted
+			}
+			conn.SSH.KeepPassphrase(c.SSH)
 			conns[i] = conn
 			found = true
 			break
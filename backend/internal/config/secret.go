@@ -0,0 +1,186 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// masterKeyEnv holds the key for saved passwords: 32 bytes, base64 or
+	// hex encoded. Without it the key is read from masterKeyFileEnv or
+	// DataDir()/master.key, which is created on first use.
+	masterKeyEnv     = "DRAWDB_MASTER_KEY"
+	masterKeyFileEnv = "DRAWDB_MASTER_KEY_FILE"
+	masterKeyFile    = "master.key"
+	masterKeySize    = 32
+
+	encryptedPrefix = "v1:"
+)
+
+var (
+	keyMu     sync.Mutex
+	masterKey []byte
+)
+
+// getMasterKey loads (or creates) the key used to encrypt saved passwords
+func getMasterKey() ([]byte, error) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	if masterKey != nil {
+		return masterKey, nil
+	}
+
+	if env := os.Getenv(masterKeyEnv); env != "" {
+		key, err := decodeKey(env)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", masterKeyEnv, err)
+		}
+		masterKey = key
+		return key, nil
+	}
+
+	path := os.Getenv(masterKeyFileEnv)
+	explicit := path != ""
+	if !explicit {
+		path = filepath.Join(DataDir(), masterKeyFile)
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		key, err := decodeKey(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("key file %s: %v", path, err)
+		}
+		masterKey = key
+		return key, nil
+	case !os.IsNotExist(err) || explicit:
+		return nil, fmt.Errorf("reading key file: %v", err)
+	}
+
+	key := make([]byte, masterKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	// O_EXCL: never overwrite a key that would make saved passwords unreadable
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("creating key file: %v", err)
+	}
+	_, err = f.WriteString(base64.StdEncoding.EncodeToString(key) + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("writing key file: %v", err)
+	}
+	masterKey = key
+	return key, nil
+}
+
+func decodeKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if key, err := base64.StdEncoding.DecodeString(s); err == nil && len(key) == masterKeySize {
+		return key, nil
+	}
+	if key, err := hex.DecodeString(s); err == nil && len(key) == masterKeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("key must be %d bytes, base64 or hex encoded", masterKeySize)
+}
+
+// encryptSecret seals plain with AES-256-GCM as "v1:" + base64(nonce|ciphertext)
+func encryptSecret(plain string) (string, error) {
+	gcm, err := newGCM()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptSecret(enc string) (string, error) {
+	if !strings.HasPrefix(enc, encryptedPrefix) {
+		return "", errors.New("unknown encryption format")
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(enc, encryptedPrefix))
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM()
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("cannot decrypt (wrong master key?)")
+	}
+	return string(plain), nil
+}
+
+func newGCM() (cipher.AEAD, error) {
+	key, err := getMasterKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ResolvePassword returns the password of c: read from PasswordEnv or
+// PasswordFile when set, otherwise the stored one
+func (c *ConnectionConfig) ResolvePassword() (string, error) {
+	switch {
+	case c.PasswordEnv != "":
+		v, ok := os.LookupEnv(c.PasswordEnv)
+		if !ok {
+			return "", fmt.Errorf("password variable %s is not set", c.PasswordEnv)
+		}
+		return v, nil
+	case c.PasswordFile != "":
+		data, err := os.ReadFile(c.PasswordFile)
+		if err != nil {
+			return "", fmt.Errorf("reading password file: %v", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	return c.Password, nil
+}
+
+// CheckRequest rejects password references in a connection sent over
+// HTTP. PasswordEnv and PasswordFile are only honoured from connections.json
+// on disk: taken from a request, they would send any environment variable
+// or readable file as the password to a host the caller chooses.
+func (c *ConnectionConfig) CheckRequest() error {
+	if c.hasSecretRef() {
+		return errors.New("password_env and password_file can only be set in connections.json")
+	}
+	return nil
+}
+
+// hasSecretRef reports whether the password lives outside connections.json
+func (c *ConnectionConfig) hasSecretRef() bool {
+	return c.PasswordEnv != "" || c.PasswordFile != ""
+}
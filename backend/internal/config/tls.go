@@ -0,0 +1,119 @@
+package config
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// SSL modes of a connection, as in the mysql client's --ssl-mode
+const (
+	SSLDisable    = "disable"
+	SSLPreferred  = "preferred"
+	SSLRequired   = "required"
+	SSLVerifyCA   = "verify-ca"
+	SSLVerifyFull = "verify-full"
+)
+
+// TLSConfig returns the TLS settings for SSLMode, or nil when TLS is off.
+// preferred and required encrypt without checking the server certificate;
+// verify-ca checks it against SSLCA (or the system roots) and verify-full
+// also checks that it was issued for Host.
+func (c *ConnectionConfig) TLSConfig() (*tls.Config, error) {
+	switch c.SSLMode {
+	case "", SSLDisable:
+		return nil, nil
+	case SSLPreferred, SSLRequired, SSLVerifyCA, SSLVerifyFull:
+	default:
+		return nil, fmt.Errorf("unknown ssl_mode %q (use disable, preferred, required, verify-ca or verify-full)", c.SSLMode)
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.SSLCert != "" || c.SSLKey != "" {
+		if c.SSLCert == "" || c.SSLKey == "" {
+			return nil, errors.New("ssl_cert and ssl_key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(c.SSLCert, c.SSLKey)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %v", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	var roots *x509.CertPool
+	if c.SSLCA != "" {
+		pem, err := os.ReadFile(c.SSLCA)
+		if err != nil {
+			return nil, fmt.Errorf("reading ssl_ca: %v", err)
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ssl_ca %s holds no PEM certificates", c.SSLCA)
+		}
+	}
+
+	switch c.SSLMode {
+	case SSLPreferred, SSLRequired:
+		cfg.InsecureSkipVerify = true
+	case SSLVerifyCA:
+		// the standard check includes the host name, so the chain is
+		// verified here instead
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyPeerCertificate = func(raw [][]byte, _ [][]*x509.Certificate) error {
+			return verifyChain(raw, roots)
+		}
+	case SSLVerifyFull:
+		cfg.RootCAs = roots
+		cfg.ServerName = c.Host
+	}
+	return cfg, nil
+}
+
+func verifyChain(raw [][]byte, roots *x509.CertPool) error {
+	if len(raw) == 0 {
+		return errors.New("server sent no certificate")
+	}
+	certs := make([]*x509.Certificate, len(raw))
+	for i, der := range raw {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return err
+		}
+		certs[i] = cert
+	}
+	opts := x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(opts)
+	return err
+}
+
+// tlsParams registers the TLS settings of c with the MySQL driver and
+// returns the DSN parameters selecting them
+func tlsParams(c *ConnectionConfig) (string, error) {
+	cfg, err := c.TLSConfig()
+	if err != nil || cfg == nil {
+		return "", err
+	}
+	// one registration per set of TLS settings: testing an edited
+	// connection registers its own and leaves the live pool's alone
+	settings := strings.Join([]string{c.SSLMode, c.SSLCA, c.SSLCert, c.SSLKey, c.Host}, "\x00")
+	sum := sha256.Sum256([]byte(settings))
+	key := "conn-" + hex.EncodeToString(sum[:8])
+	if err := mysql.RegisterTLSConfig(key, cfg); err != nil {
+		return "", err
+	}
+	params := "&tls=" + key
+	if c.SSLMode == SSLPreferred {
+		params += "&allowFallbackToPlaintext=true"
+	}
+	return params, nil
+}
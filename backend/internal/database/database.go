@@ -1,7 +1,9 @@
 package database
 
 import (
+	"backend/internal/config"
 	"log"
+	"sync"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -9,13 +11,52 @@ import (
 
 var DB *gorm.DB
 
-func Connect(dsn string) {
-	var err error
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+var (
+	tunnelMu sync.Mutex
+	// tunnel serves DB when the active connection uses SSH
+	tunnel *Tunnel
+)
+
+func Connect(dsn string, ssh *config.SSHConfig) {
+	db, t, err := Open(dsn, ssh)
 	if err != nil {
 		log.Printf("Warning: Failed to connect to database: %v", err)
         DB = nil // PENTING: Paksa nil supaya repo tahu kalau ini gagal
-	} else {
-		log.Println("Database connected successfully")
+		return
+	}
+	DB = db
+	log.Println("Database connected successfully")
+
+	// the previous connection is replaced, its tunnel is no longer needed
+	tunnelMu.Lock()
+	old := tunnel
+	tunnel = t
+	tunnelMu.Unlock()
+	if old != nil {
+		old.Close()
+	}
+}
+
+// Open connects to MySQL, through an SSH tunnel when ssh is set. The tunnel
+// (nil without ssh) must be closed once the returned DB is no longer used.
+func Open(dsn string, ssh *config.SSHConfig) (*gorm.DB, *Tunnel, error) {
+	var t *Tunnel
+	if ssh != nil {
+		var err error
+		if t, err = OpenTunnel(ssh); err != nil {
+			return nil, nil, err
+		}
+		if dsn, err = t.DSN(dsn); err != nil {
+			t.Close()
+			return nil, nil, err
+		}
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	if err != nil {
+		if t != nil {
+			t.Close()
+		}
+		return nil, nil, err
 	}
+	return db, t, nil
 }
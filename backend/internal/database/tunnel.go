@@ -0,0 +1,266 @@
+package database
+
+import (
+	"backend/internal/config"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	sshDialTimeout = 10 * time.Second
+	// sshKeepAlive detects a dead bastion so the next query reconnects
+	// instead of hanging on the old session
+	sshKeepAlive = 30 * time.Second
+)
+
+var tunnelSeq int64
+
+// Tunnel forwards MySQL connections through an SSH bastion. It registers a
+// dial network with the MySQL driver; DSNs rewritten by Tunnel.DSN use it.
+// The SSH session is opened on first use and reopened after it drops.
+type Tunnel struct {
+	network string
+	config  *ssh.ClientConfig
+	addr    string
+	// useAgent adds the ssh-agent keys at each login
+	useAgent bool
+
+	mu     sync.Mutex
+	client *ssh.Client
+	closed bool
+}
+
+// OpenTunnel checks the SSH settings and connects to the bastion
+func OpenTunnel(cfg *config.SSHConfig) (*Tunnel, error) {
+	if cfg.Host == "" || cfg.User == "" {
+		return nil, errors.New("SSH host and user are required")
+	}
+	if cfg.KeyPath == "" && !cfg.UseAgent {
+		return nil, errors.New("SSH needs a key path or the agent")
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = 22
+	}
+
+	var auth []ssh.AuthMethod
+	if cfg.KeyPath != "" {
+		signer, err := loadKey(cfg.KeyPath, cfg.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+
+	knownHosts := cfg.KnownHosts
+	if knownHosts == "" {
+		knownHosts = "~/.ssh/known_hosts"
+	}
+	hostKeys, err := knownhosts.New(expandHome(knownHosts))
+	if err != nil {
+		return nil, fmt.Errorf("reading known_hosts: %v", err)
+	}
+
+	t := &Tunnel{
+		network: "ssh" + strconv.FormatInt(atomic.AddInt64(&tunnelSeq, 1), 10),
+		config: &ssh.ClientConfig{
+			User:            cfg.User,
+			Auth:            auth,
+			HostKeyCallback: hostKeys,
+			Timeout:         sshDialTimeout,
+		},
+		addr:     net.JoinHostPort(cfg.Host, strconv.Itoa(port)),
+		useAgent: cfg.UseAgent,
+	}
+	if _, err := t.session(context.Background()); err != nil {
+		return nil, err
+	}
+	mysql.RegisterDialContext(t.network, t.dial)
+	return t, nil
+}
+
+// DSN rewrites a MySQL DSN to connect through the tunnel. The address in
+// the DSN is resolved on the bastion.
+func (t *Tunnel) DSN(dsn string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	cfg.Net = t.network
+	return cfg.FormatDSN(), nil
+}
+
+// Close ends the SSH session; connections through it are dropped
+func (t *Tunnel) Close() error {
+	mysql.DeregisterDialContext(t.network)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	if t.client == nil {
+		return nil
+	}
+	err := t.client.Close()
+	t.client = nil
+	return err
+}
+
+func (t *Tunnel) dial(ctx context.Context, addr string) (net.Conn, error) {
+	client, err := t.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.DialContext(ctx, "tcp", addr)
+	// an OpenChannelError comes from a live bastion that could not reach addr
+	if err == nil || ctx.Err() != nil || errors.As(err, new(*ssh.OpenChannelError)) {
+		return conn, err
+	}
+	// the session may have died since the last keepalive: retry once on
+	// a new one
+	t.drop(client)
+	if client, err = t.session(ctx); err != nil {
+		return nil, err
+	}
+	return client.DialContext(ctx, "tcp", addr)
+}
+
+// session returns the SSH client, connecting when there is none
+func (t *Tunnel) session(ctx context.Context) (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, errors.New("SSH tunnel is closed")
+	}
+	if t.client != nil {
+		return t.client, nil
+	}
+
+	clientConfig := *t.config
+	if t.useAgent {
+		sock, err := dialAgent()
+		if err != nil && len(clientConfig.Auth) == 0 {
+			return nil, err
+		}
+		if err == nil {
+			defer sock.Close()
+			auth := append([]ssh.AuthMethod{}, clientConfig.Auth...)
+			clientConfig.Auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(sock).Signers))
+		}
+	}
+
+	d := net.Dialer{Timeout: sshDialTimeout}
+	conn, err := d.DialContext(ctx, "tcp", t.addr)
+	if err != nil {
+		return nil, fmt.Errorf("SSH connection to %s failed: %v", t.addr, err)
+	}
+	// the handshake honours ctx only through the deadline
+	deadline := time.Now().Add(sshDialTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+	c, chans, reqs, err := ssh.NewClientConn(conn, t.addr, &clientConfig)
+	if err != nil {
+		conn.Close()
+		if errors.As(err, new(*knownhosts.KeyError)) {
+			return nil, fmt.Errorf("SSH host key of %s is unknown or changed; add it to known_hosts (ssh-keyscan): %v", t.addr, err)
+		}
+		return nil, fmt.Errorf("SSH login to %s failed: %v", t.addr, err)
+	}
+	conn.SetDeadline(time.Time{})
+	client := ssh.NewClient(c, chans, reqs)
+	t.client = client
+	go t.keepAlive(client)
+	return client, nil
+}
+
+// keepAlive pings the bastion until the session ends
+func (t *Tunnel) keepAlive(client *ssh.Client) {
+	done := make(chan struct{})
+	go func() {
+		client.Wait()
+		close(done)
+	}()
+	ticker := time.NewTicker(sshKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			t.drop(client)
+			return
+		case <-ticker.C:
+			if _, _, err := client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+				t.drop(client)
+				return
+			}
+		}
+	}
+}
+
+// drop forgets a dead session so the next dial reconnects
+func (t *Tunnel) drop(client *ssh.Client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client == client {
+		t.client = nil
+	}
+	client.Close()
+}
+
+func loadKey(path, passphrase string) (ssh.Signer, error) {
+	pem, err := os.ReadFile(expandHome(path))
+	if err != nil {
+		return nil, fmt.Errorf("reading SSH key: %v", err)
+	}
+	var signer ssh.Signer
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(pem, []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(pem)
+	}
+	if errors.As(err, new(*ssh.PassphraseMissingError)) {
+		return nil, errors.New("SSH key is encrypted; a passphrase is required")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("SSH key %s: %v", path, err)
+	}
+	return signer, nil
+}
+
+// dialAgent connects to the ssh-agent, which must stay connected while
+// its keys sign the login
+func dialAgent() (net.Conn, error) {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, errors.New("SSH_AUTH_SOCK is not set")
+	}
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to ssh-agent: %v", err)
+	}
+	return conn, nil
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
@@ -2,10 +2,23 @@ package domain
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
 
 	"gorm.io/gorm"
 )
 
+var (
+	// ErrNotFound is wrapped by services when a named resource does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidInput is wrapped when a request is well-formed JSON but
+	// cannot be executed (unknown column, bad operator, ...)
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrReadOnly is returned for changes on a read-only connection
+	ErrReadOnly = errors.New("connection is read-only")
+)
+
 // Entities (Data Structures)
 type ColumnDefinition struct {
 	Name            string `json:"name"`
@@ -55,15 +68,497 @@ type RelationSchema struct {
 	TargetColumn string `json:"target_column"`
 }
 
+// DefaultLayoutName is used when a client does not ask for a named layout
+const DefaultLayoutName = "default"
+
+// LayoutKey identifies a diagram layout in the local metadata store.
+// Several named layouts can exist for the same database.
+type LayoutKey struct {
+	Connection string `json:"connection"`
+	Database   string `json:"database"`
+	Name       string `json:"name"`
+}
+
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type Bounds struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// NodeLayout is the position and look of one table node
+type NodeLayout struct {
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Width     float64 `json:"width,omitempty"`
+	Height    float64 `json:"height,omitempty"`
+	Color     string  `json:"color,omitempty"`
+	Collapsed bool    `json:"collapsed,omitempty"`
+}
+
+// GroupLayout is a labelled box around a set of tables
+type GroupLayout struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Color  string   `json:"color,omitempty"`
+	Tables []string `json:"tables"`
+	Bounds Bounds   `json:"bounds"`
+}
+
+// NoteLayout is a free-text sticky note on the canvas
+type NoteLayout struct {
+	ID     string `json:"id"`
+	Text   string `json:"text"`
+	Color  string `json:"color,omitempty"`
+	Bounds Bounds `json:"bounds"`
+}
+
+// EdgeLayout stores manual routing points for a relation line.
+// ID is "source_table.source_column->target_table.target_column".
+type EdgeLayout struct {
+	ID     string  `json:"id"`
+	Points []Point `json:"points"`
+}
+
+// Layout is everything the diagram canvas persists for one layout
+type Layout struct {
+	Nodes  map[string]NodeLayout `json:"nodes"`
+	Groups []GroupLayout         `json:"groups"`
+	Notes  []NoteLayout          `json:"notes"`
+	Edges  []EdgeLayout          `json:"edges"`
+}
+
+// DiagramView is a named subject area: a subset of the schema's tables.
+// A table belongs to the view if it is listed, matches one of the glob
+// patterns, or lies within Around.Hops relations of Around.Table.
+// Each view is drawn with the layout of the same name.
+type DiagramView struct {
+	Name     string            `json:"name"`
+	Tables   []string          `json:"tables,omitempty"`
+	Patterns []string          `json:"patterns,omitempty"`
+	Around   *ViewNeighborhood `json:"around,omitempty"`
+}
+
+type ViewNeighborhood struct {
+	Table string `json:"table"`
+	Hops  int    `json:"hops"`
+}
+
+// Filter operators for FilterCondition.Operator
+const (
+	OpEq         = "eq"
+	OpNotEq      = "ne"
+	OpLt         = "lt"
+	OpLte        = "lte"
+	OpGt         = "gt"
+	OpGte        = "gte"
+	OpLike       = "like"
+	OpNotLike    = "not_like"
+	OpContains   = "contains"
+	OpStartsWith = "starts_with"
+	OpEndsWith   = "ends_with"
+	OpIn         = "in"
+	OpNotIn      = "not_in"
+	OpBetween    = "between"
+	OpIsNull     = "is_null"
+	OpNotNull    = "not_null"
+)
+
+// FilterCondition compares one column with a value. Value is an array for
+// in/not_in, a two element array for between and unused for null checks.
+type FilterCondition struct {
+	Column   string      `json:"column"`
+	Operator string      `json:"op"`
+	Value    interface{} `json:"value,omitempty"`
+}
+
+// FilterGroup joins conditions and nested groups with "and" (default) or "or"
+type FilterGroup struct {
+	Logic      string            `json:"logic,omitempty"`
+	Conditions []FilterCondition `json:"conditions,omitempty"`
+	Groups     []FilterGroup     `json:"groups,omitempty"`
+}
+
+type SortField struct {
+	Column string `json:"column"`
+	Desc   bool   `json:"desc,omitempty"`
+}
+
+// How TableData.Total is computed
+const (
+	CountExact    = "exact"    // COUNT(*) with the filter applied
+	CountEstimate = "estimate" // optimizer estimate, cheap on huge tables
+	CountNone     = "none"     // skip counting, Total is -1
+)
+
+// DataQuery selects which rows of a table the data browser reads.
+// With Keyset set, rows are paged by cursor on the sort columns plus the
+// primary key instead of by Offset; Cursor is empty for the first page.
+// Text and binary values longer than MaxValueSize (0 = no limit) are
+// truncated in the response.
+type DataQuery struct {
+	Limit        int
+	Offset       int
+	Filter       *FilterGroup
+	Sort         []SortField
+	Keyset       bool
+	Cursor       string
+	Count        string
+	MaxValueSize int
+}
+
+// ColumnMeta describes a table column for the data browser
+type ColumnMeta struct {
+	Name            string  `json:"name"`
+	Type            string  `json:"type"`
+	Nullable        bool    `json:"nullable"`
+	IsPK            bool    `json:"is_pk"`
+	IsAutoIncrement bool    `json:"is_ai,omitempty"`
+	IsGenerated     bool    `json:"is_generated,omitempty"` // computed; cannot be written
+	Default         *string `json:"default,omitempty"`
+}
+
+// RowChange identifies one row and, for updates, its new values.
+// Key holds the primary key columns; tables without a primary key must
+// send the complete original row in Row instead.
+type RowChange struct {
+	Key    map[string]interface{} `json:"key,omitempty"`
+	Row    map[string]interface{} `json:"row,omitempty"`
+	Values map[string]interface{} `json:"values,omitempty"`
+}
+
+// Changeset operation types
+const (
+	ChangeInsert = "insert"
+	ChangeUpdate = "update"
+	ChangeDelete = "delete"
+)
+
+// RowOperation is one step of a changeset. Inserts use Values; updates and
+// deletes identify the row like RowChange. A value of {"$ref": n} is
+// replaced by the insert id of operation n, so children can point at a
+// parent inserted earlier in the same changeset.
+type RowOperation struct {
+	Type  string `json:"type"`
+	Table string `json:"table"`
+	RowChange
+}
+
+type Changeset struct {
+	Operations []RowOperation `json:"operations"`
+}
+
+// RowWrite is a changeset operation once the target row has been resolved
+type RowWrite struct {
+	Type      string
+	Table     string
+	Condition map[string]interface{}
+	Values    map[string]interface{}
+}
+
+type OperationResult struct {
+	Index    int    `json:"index"`
+	Type     string `json:"type"`
+	Table    string `json:"table"`
+	Status   string `json:"status"` // ok, failed, skipped
+	Affected int64  `json:"affected"`
+	InsertID int64  `json:"insert_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type ChangesetResult struct {
+	Committed bool              `json:"committed"`
+	Results   []OperationResult `json:"results"`
+}
+
+// RowStream iterates a result set without loading it into memory
+type RowStream interface {
+	Columns() []string
+	Next() bool
+	Values() []interface{}
+	Err() error
+	Close() error
+}
+
+// Export is an opened export, ready to be streamed to the client
+type Export struct {
+	ContentType string
+	Filename    string
+	// Write streams every row to w and releases the database cursor
+	Write func(w io.Writer) error
+}
+
+// BinaryValue is how binary column values appear in TableData rows:
+// short values as hex, longer ones as base64
+type BinaryValue struct {
+	Encoding  string `json:"$binary"` // hex or base64
+	Data      string `json:"data"`
+	Size      int    `json:"size"`
+	Truncated bool   `json:"truncated,omitempty"`
+	// URL fetches the full value when Truncated is set
+	URL string `json:"url,omitempty"`
+}
+
+// TruncatedValue replaces a text value longer than DataQuery.MaxValueSize
+type TruncatedValue struct {
+	Value     string `json:"value"`
+	Size      int    `json:"size"` // full length in characters
+	Truncated bool   `json:"$truncated"`
+	URL       string `json:"url,omitempty"`
+}
+
+// CellValue is one complete value, fetched by primary key
+type CellValue struct {
+	Column ColumnMeta
+	Binary bool
+	Data   []byte // nil for NULL
+}
+
+// TableData is one page of rows. Values are serialized by column type:
+// DECIMAL as strings, dates as ISO-8601, binary as BinaryValue, and
+// integers beyond 2^53 as strings so JavaScript clients do not lose digits.
 type TableData struct {
-	Columns []string                 `json:"columns"`
-	Rows    []map[string]interface{} `json:"rows"`
-	Total   int64                    `json:"total"`
+	Columns        []string                 `json:"columns"`
+	ColumnMeta     []ColumnMeta             `json:"column_meta"`
+	Rows           []map[string]interface{} `json:"rows"`
+	Total          int64                    `json:"total"`
+	TotalEstimated bool                     `json:"total_estimated,omitempty"`
+	// NextCursor is set in keyset mode when more rows follow
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ImportOptions controls a bulk import. Mapping maps source fields (CSV
+// headers or JSON keys) to column names; an empty target skips a field.
+// Without a mapping fields are matched to columns by name. Sheet selects
+// the worksheet of an XLSX upload (default: the first).
+type ImportOptions struct {
+	Format    string            `json:"format"`
+	Sheet     string            `json:"sheet,omitempty"`
+	Mapping   map[string]string `json:"mapping,omitempty"`
+	ChunkSize int               `json:"chunk_size,omitempty"`
+	DryRun    bool              `json:"dry_run,omitempty"`
+}
+
+// RejectedRow is a source record that was not imported. Row counts data
+// records from 1, not counting the CSV header.
+type RejectedRow struct {
+	Row    int    `json:"row"`
+	Reason string `json:"reason"`
+}
+
+type ImportResult struct {
+	Inserted      int64         `json:"inserted"`
+	Columns       []string      `json:"columns"`
+	Ignored       []string      `json:"ignored,omitempty"`
+	Rejected      []RejectedRow `json:"rejected"` // capped; see RejectedCount
+	RejectedCount int           `json:"rejected_count"`
+	DryRun        bool          `json:"dry_run,omitempty"`
+}
+
+// ForeignKey is a (possibly composite) foreign key constraint of Table
+type ForeignKey struct {
+	Name       string   `json:"name"`
+	Table      string   `json:"table"`
+	Columns    []string `json:"columns"`
+	RefTable   string   `json:"ref_table"`
+	RefColumns []string `json:"ref_columns"`
+}
+
+// TableConstraints lists the keys a data generator has to respect.
+// UniqueKeys includes the primary key.
+type TableConstraints struct {
+	ForeignKeys []ForeignKey `json:"foreign_keys"`
+	UniqueKeys  [][]string   `json:"unique_keys"`
+}
+
+// GenerateOptions controls synthetic data generation. ParentRows is the
+// number of rows generated for each empty parent table (default Rows/5).
+// A non-zero Seed makes the output reproducible.
+type GenerateOptions struct {
+	Rows       int   `json:"rows"`
+	ParentRows int   `json:"parent_rows,omitempty"`
+	Seed       int64 `json:"seed,omitempty"`
+}
+
+type GeneratedTable struct {
+	Table    string `json:"table"`
+	Inserted int64  `json:"inserted"`
+	// Skipped counts rows dropped because no unused unique value was found
+	Skipped int `json:"skipped,omitempty"`
+}
+
+// GenerateResult lists tables in insertion order, parents first
+type GenerateResult struct {
+	Tables []GeneratedTable `json:"tables"`
+}
+
+// RelatedQuery identifies the row whose related rows are loaded. PK is the
+// value of a single-column primary key; composite keys use Key instead.
+// Limit caps the child rows returned per relation.
+type RelatedQuery struct {
+	Table        string
+	PK           string
+	Key          map[string]interface{}
+	Limit        int
+	MaxValueSize int
+}
+
+// RelatedParent is the row a foreign key of the selected row points to;
+// Row is nil when the key is NULL or dangling
+type RelatedParent struct {
+	Relation ForeignKey             `json:"relation"`
+	Row      map[string]interface{} `json:"row"`
+}
+
+// RelatedChildren are the rows of another table pointing at the selected row
+type RelatedChildren struct {
+	Relation ForeignKey               `json:"relation"`
+	Rows     []map[string]interface{} `json:"rows"`
+	Total    int64                    `json:"total"`
+	HasMore  bool                     `json:"has_more"`
+}
+
+type RelatedRows struct {
+	Table    string                 `json:"table"`
+	Key      map[string]interface{} `json:"key"`
+	Row      map[string]interface{} `json:"row"`
+	Parents  []RelatedParent        `json:"parents"`
+	Children []RelatedChildren      `json:"children"`
+}
+
+// Statement types reported by the SQL console
+const (
+	StmtSelect      = "select" // any statement returning rows: SELECT, SHOW, EXPLAIN, ...
+	StmtInsert      = "insert"
+	StmtUpdate      = "update"
+	StmtDelete      = "delete"
+	StmtDDL         = "ddl"
+	StmtTransaction = "transaction"
+	StmtSession     = "session" // USE, SET
+	StmtCall        = "call"
+	StmtOther       = "other"
+)
+
+// ConsoleRequest is a statement or script for the SQL console. Database
+// selects the schema to run in (USE) before the first statement. A script
+// stops at the first failing statement unless ContinueOnError is set.
+// QueryID is chosen by the client so it can cancel the script while it
+// runs; TimeoutSeconds overrides the server's per-statement timeout.
+type ConsoleRequest struct {
+	Query           string `json:"query"`
+	Database        string `json:"database,omitempty"`
+	MaxRows         int    `json:"max_rows,omitempty"`
+	ContinueOnError bool   `json:"continue_on_error,omitempty"`
+	QueryID         string `json:"query_id,omitempty"`
+	TimeoutSeconds  int    `json:"timeout_seconds,omitempty"`
+
+	// Parameters declares the :name placeholders of a saved query and
+	// Params holds the values bound to them
+	Parameters []QueryParameter        `json:"-"`
+	Params     map[string]interface{} `json:"-"`
+}
+
+// ResultColumn describes a column of a console result set. Type is the
+// driver's type name (VARCHAR, DECIMAL, ...).
+type ResultColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+// StatementResult is the outcome of one console statement. Rows are
+// arrays in column order because result sets may repeat column names.
+type StatementResult struct {
+	Index        int             `json:"index"`
+	Line         int             `json:"line"` // line of the script the statement starts on
+	Statement    string          `json:"statement"`
+	Type         string          `json:"type"`
+	Columns      []ResultColumn  `json:"columns,omitempty"`
+	Rows         [][]interface{} `json:"rows,omitempty"`
+	Truncated    bool            `json:"truncated,omitempty"` // more rows than MaxRows
+	RowsAffected int64           `json:"rows_affected"`
+	LastInsertID int64           `json:"last_insert_id,omitempty"`
+	DurationMs   float64         `json:"duration_ms"`
+	Error        string          `json:"error,omitempty"`
+	Skipped      bool            `json:"skipped,omitempty"` // not run because an earlier statement failed
+}
+
+type QueryResult struct {
+	Results    []StatementResult `json:"results"`
+	DurationMs float64           `json:"duration_ms"`
+}
+
+// ExplainRequest asks for the plan of one statement. Analyze also executes
+// it (SELECT only) to report actual rows and timing.
+type ExplainRequest struct {
+	Query    string `json:"query"`
+	Database string `json:"database,omitempty"`
+	Analyze  bool   `json:"analyze,omitempty"`
+}
+
+// PlanNode is one step of a normalized query plan. Table is the real table
+// behind Alias when the step reads one. Actual figures are only set on an
+// analyzed plan; ActualTimeMs is per loop, as the server reports it.
+type PlanNode struct {
+	Operation     string     `json:"operation"`
+	Table         string     `json:"table,omitempty"`
+	Alias         string     `json:"alias,omitempty"`
+	AccessType    string     `json:"access_type,omitempty"` // ALL, index, range, ref, eq_ref, const, ...
+	Key           string     `json:"key,omitempty"`
+	PossibleKeys  []string   `json:"possible_keys,omitempty"`
+	Condition     string     `json:"condition,omitempty"`
+	Cost          *float64   `json:"cost,omitempty"`
+	EstimatedRows *float64   `json:"estimated_rows,omitempty"`
+	Filtered      *float64   `json:"filtered,omitempty"` // percent of rows kept by the condition
+	ActualRows    *float64   `json:"actual_rows,omitempty"`
+	Loops         *float64   `json:"loops,omitempty"`
+	ActualTimeMs  *float64   `json:"actual_time_ms,omitempty"`
+	Warnings      []string   `json:"warnings,omitempty"` // full scan, filesort, ...
+	Children      []PlanNode `json:"children,omitempty"`
+}
+
+// ExplainResult is the estimated plan and, when analyzed, the executed
+// one. Tables are the schema tables the plan reads, so they can be shown
+// next to the diagram; Warnings collects the warnings of all nodes.
+type ExplainResult struct {
+	Plan        PlanNode        `json:"plan"`
+	Analyzed    *PlanNode       `json:"analyzed,omitempty"`
+	Tables      []TableSchema   `json:"tables"`
+	Warnings    []string        `json:"warnings"`
+	Raw         json.RawMessage `json:"raw"`                    // EXPLAIN FORMAT=JSON output
+	AnalyzeText string          `json:"analyze_text,omitempty"` // EXPLAIN ANALYZE output
 }
 
+// SQLSession runs console statements on one dedicated connection, so that
+// session state (USE, variables, LAST_INSERT_ID) carries over between them
+type SQLSession interface {
+	// Run executes one statement; returnsRows selects a query over an exec.
+	// Result rows hold raw driver values, at most maxRows of them. args are
+	// bound to the statement's ? placeholders.
+	Run(ctx context.Context, statement string, returnsRows bool, maxRows int, args ...interface{}) (*StatementResult, error)
+	// Use switches the session's default database
+	Use(ctx context.Context, database string) error
+	// ConnectionID is the server's CONNECTION_ID() for the session
+	ConnectionID() int64
+	Close() error
+}
+
+// DefaultValue as a bound value makes the repository write DEFAULT,
+// letting the column's default apply inside a multi-row insert
+type DefaultValue struct{}
+
 // Repositories Interfaces (DB Access)
 type SchemaRepository interface {
-	SetDB(db *gorm.DB)
+	// SetDB switches the connection. readOnly makes the repository refuse
+	// every change to the database; console sessions run in read-only
+	// transactions
+	SetDB(db *gorm.DB, readOnly bool)
+	ReadOnly() bool
 	GetDatabases(ctx context.Context) ([]string, error)
 	CreateDatabase(ctx context.Context, name string) error
 	DropDatabase(ctx context.Context, name string) error
@@ -72,16 +567,44 @@ type SchemaRepository interface {
 	SyncBatch(ctx context.Context, dbName string, reqs []TableRequest) error
 	DropTable(ctx context.Context, name string) error
 
-	GetTableData(ctx context.Context, tableName string, limit, offset int) (*TableData, error)
+	GetTableData(ctx context.Context, tableName string, q DataQuery) (*TableData, error)
+	GetColumns(ctx context.Context, tableName string) ([]ColumnMeta, error)
+	// GetValue reads one column of the row matching key; ErrNotFound if none
+	GetValue(ctx context.Context, tableName, column string, key map[string]interface{}) (interface{}, error)
 	InsertData(ctx context.Context, tableName string, data map[string]interface{}) error
-	DeleteData(ctx context.Context, tableName string, condition map[string]interface{}) error
+	// UpdateData and DeleteData touch at most one row matching condition
+	UpdateData(ctx context.Context, tableName string, condition, values map[string]interface{}) (int64, error)
+	DeleteData(ctx context.Context, tableName string, condition map[string]interface{}) (int64, error)
+	// ApplyWrites runs all writes in one transaction; any failure rolls back
+	ApplyWrites(ctx context.Context, writes []RowWrite) (*ChangesetResult, error)
+	// InsertBatches opens a transaction and hands fill an insert function
+	// for multi-row INSERTs; it commits only if fill returns nil
+	InsertBatches(ctx context.Context, tableName string, fill func(insert func(columns []string, rows [][]interface{}) error) error) (int64, error)
+	GetConstraints(ctx context.Context, tableName string) (*TableConstraints, error)
+	// GetReferencingKeys lists foreign keys of other tables pointing at tableName
+	GetReferencingKeys(ctx context.Context, tableName string) ([]ForeignKey, error)
+	// SampleRows returns up to limit distinct non-NULL tuples of columns
+	SampleRows(ctx context.Context, tableName string, columns []string, limit int) ([][]interface{}, error)
+
+	// StreamTable and StreamQuery open cursors for exports; close them after use
+	StreamTable(ctx context.Context, tableName string, q DataQuery) (RowStream, error)
+	StreamQuery(ctx context.Context, query string) (RowStream, error)
 
 	ExecuteRaw(ctx context.Context, query string) ([]map[string]interface{}, error)
     ExecuteDDL(ctx context.Context, query string) error
+	// OpenSession reserves a connection for the SQL console
+	OpenSession(ctx context.Context) (SQLSession, error)
     
-    // Layout
-    SaveLayout(ctx context.Context, layouts map[string]interface{}) error
-    GetLayout(ctx context.Context) (map[string]interface{}, error)
+    // Layout (legacy _layout tables, read only for migration)
+    GetLegacyLayout(ctx context.Context, dbName string) (*Layout, error)
+}
+
+// LayoutRepository persists layouts outside the user's database
+type LayoutRepository interface {
+	Get(ctx context.Context, key LayoutKey) (*Layout, bool, error)
+	Save(ctx context.Context, key LayoutKey, layout *Layout) error
+	Delete(ctx context.Context, key LayoutKey) error
+	List(ctx context.Context, connection, database string) ([]string, error)
 }
 
 // ... (Existing services)
@@ -90,19 +613,86 @@ type SyncService interface {
 	GetSchema(ctx context.Context, dbName string) (*DatabaseSchema, error)
 }
 
+// ViewRepository persists diagram views in the local metadata store
+type ViewRepository interface {
+	List(ctx context.Context, connection, database string) ([]DiagramView, error)
+	Get(ctx context.Context, connection, database, name string) (*DiagramView, bool, error)
+	Save(ctx context.Context, connection, database string, view DiagramView) error
+	Delete(ctx context.Context, connection, database, name string) error
+}
+
+// QueryRepository persists console history and saved queries in the
+// local metadata store, per connection
+type QueryRepository interface {
+	AddHistory(ctx context.Context, connection string, entry HistoryEntry) error
+	SearchHistory(ctx context.Context, connection string, q HistoryQuery) (*HistoryPage, error)
+	ClearHistory(ctx context.Context, connection string) error
+	ListSaved(ctx context.Context, connection string) ([]SavedQuery, error)
+	GetSaved(ctx context.Context, connection, name string) (*SavedQuery, bool, error)
+	SaveQuery(ctx context.Context, connection string, q SavedQuery) error
+	DeleteSaved(ctx context.Context, connection, name string) error
+}
+
+type QueryService interface {
+	// Record adds a console run to the history; err is the error Execute
+	// returned, if any
+	Record(ctx context.Context, connection string, req ConsoleRequest, result *QueryResult, err error) error
+	History(ctx context.Context, connection string, q HistoryQuery) (*HistoryPage, error)
+	ClearHistory(ctx context.Context, connection string) error
+	// ListSaved returns the saved queries, optionally those with tag and
+	// matching search in name, description or text
+	ListSaved(ctx context.Context, connection, tag, search string) ([]SavedQuery, error)
+	GetSaved(ctx context.Context, connection, name string) (*SavedQuery, error)
+	Save(ctx context.Context, connection string, q SavedQuery) (*SavedQuery, error)
+	DeleteSaved(ctx context.Context, connection, name string) error
+	// Run executes a saved query with params bound to its declared
+	// parameters and records it in the history
+	Run(ctx context.Context, connection, name string, params map[string]interface{}, maxRows int) (*QueryResult, error)
+}
+
+type ViewService interface {
+	List(ctx context.Context, connection, database string) ([]DiagramView, error)
+	Save(ctx context.Context, connection, database string, view DiagramView) error
+	Delete(ctx context.Context, connection, database, name string) error
+	// Schema returns only the view's tables and the relations among them
+	Schema(ctx context.Context, connection, database, name string) (*DatabaseSchema, error)
+}
+
 type LayoutService interface {
-    Save(ctx context.Context, layouts map[string]interface{}) error
-    Get(ctx context.Context) (map[string]interface{}, error)
+    Save(ctx context.Context, key LayoutKey, layout *Layout) error
+    Get(ctx context.Context, key LayoutKey) (*Layout, error)
+    Delete(ctx context.Context, key LayoutKey) error
+    List(ctx context.Context, connection, database string) ([]string, error)
+    AutoLayout(ctx context.Context, key LayoutKey, algorithm string, save bool) (*Layout, error)
 }
 
 type DatabaseService interface {
 	List(ctx context.Context) ([]string, error)
 	Create(ctx context.Context, name string) error
 	Drop(ctx context.Context, name string) error
-	Execute(ctx context.Context, query string) error
+	// Execute runs a console statement and reports its result
+	Execute(ctx context.Context, req ConsoleRequest) (*QueryResult, error)
+	// Cancel stops the running console query with the given QueryID
+	Cancel(ctx context.Context, queryID string) error
+	// Explain returns the normalized plan of one statement
+	Explain(ctx context.Context, req ExplainRequest) (*ExplainResult, error)
 }
 
 type DataService interface {
-	GetData(ctx context.Context, table string, limit, offset int) (*TableData, error)
+	GetData(ctx context.Context, table string, q DataQuery) (*TableData, error)
+	// GetRelated loads a row with its parent rows and referencing child rows
+	GetRelated(ctx context.Context, q RelatedQuery) (*RelatedRows, error)
+	// GetValue returns a complete cell value that GetData truncated
+	GetValue(ctx context.Context, table, column string, key map[string]interface{}) (*CellValue, error)
 	Insert(ctx context.Context, table string, data map[string]interface{}) error
+	Update(ctx context.Context, table string, change RowChange) (int64, error)
+	Delete(ctx context.Context, table string, change RowChange) (int64, error)
+	ApplyChangeset(ctx context.Context, cs Changeset) (*ChangesetResult, error)
+	ExportTable(ctx context.Context, table string, q DataQuery, format string) (*Export, error)
+	ExportQuery(ctx context.Context, query, format string) (*Export, error)
+	// ExportWorkbook writes the tables as sheets of one XLSX workbook
+	ExportWorkbook(ctx context.Context, tables []string, q DataQuery) (*Export, error)
+	Import(ctx context.Context, table string, r io.Reader, opts ImportOptions) (*ImportResult, error)
+	// Generate inserts synthetic rows, filling empty parent tables first
+	Generate(ctx context.Context, table string, opts GenerateOptions) (*GenerateResult, error)
 }
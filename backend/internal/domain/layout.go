@@ -0,0 +1,189 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+)
+
+const (
+	maxNoteLength  = 10000
+	maxEdgePoints  = 200
+	maxLayoutItems = 5000
+)
+
+var colorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|[a-zA-Z]{1,32})$`)
+
+// NewLayout returns an empty layout with all collections initialized
+func NewLayout() *Layout {
+	return &Layout{
+		Nodes:  map[string]NodeLayout{},
+		Groups: []GroupLayout{},
+		Notes:  []NoteLayout{},
+		Edges:  []EdgeLayout{},
+	}
+}
+
+// Normalize replaces nil collections with empty ones so clients always
+// receive arrays and objects rather than null.
+func (l *Layout) Normalize() {
+	if l.Nodes == nil {
+		l.Nodes = map[string]NodeLayout{}
+	}
+	if l.Groups == nil {
+		l.Groups = []GroupLayout{}
+	}
+	if l.Notes == nil {
+		l.Notes = []NoteLayout{}
+	}
+	if l.Edges == nil {
+		l.Edges = []EdgeLayout{}
+	}
+}
+
+// DecodeLayout reads a layout sent by a client. Unknown fields are an
+// error, so a body in another shape cannot pass as an empty layout. The
+// legacy body, {"table": {"x": 1, "y": 2}, ...}, is converted to nodes.
+func DecodeLayout(data []byte) (*Layout, error) {
+	var l Layout
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&l)
+	if err == nil {
+		l.Normalize()
+		return &l, nil
+	}
+	if legacy, ok := decodeLegacyLayout(data); ok {
+		return legacy, nil
+	}
+	return nil, fmt.Errorf("invalid layout: %v", err)
+}
+
+// decodeLegacyLayout reads the table -> position map of older clients
+func decodeLegacyLayout(data []byte) (*Layout, bool) {
+	var tables map[string]json.RawMessage
+	if json.Unmarshal(data, &tables) != nil || len(tables) == 0 {
+		return nil, false
+	}
+	l := NewLayout()
+	for name, raw := range tables {
+		var pos struct {
+			X *float64 `json:"x"`
+			Y *float64 `json:"y"`
+		}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if dec.Decode(&pos) != nil || pos.X == nil || pos.Y == nil {
+			return nil, false
+		}
+		l.Nodes[name] = NodeLayout{X: *pos.X, Y: *pos.Y}
+	}
+	return l, true
+}
+
+// Validate checks a layout coming from a client before it is stored
+func (l *Layout) Validate() error {
+	if len(l.Nodes)+len(l.Groups)+len(l.Notes)+len(l.Edges) > maxLayoutItems {
+		return fmt.Errorf("layout has too many items (max %d)", maxLayoutItems)
+	}
+
+	for name, n := range l.Nodes {
+		if name == "" {
+			return fmt.Errorf("node with empty table name")
+		}
+		if !finite(n.X, n.Y, n.Width, n.Height) {
+			return fmt.Errorf("node %s: coordinates must be finite numbers", name)
+		}
+		if n.Width < 0 || n.Height < 0 {
+			return fmt.Errorf("node %s: width and height cannot be negative", name)
+		}
+		if err := validateColor(n.Color); err != nil {
+			return fmt.Errorf("node %s: %v", name, err)
+		}
+	}
+
+	groupIDs := make(map[string]bool)
+	for _, g := range l.Groups {
+		if g.ID == "" {
+			return fmt.Errorf("group id is required")
+		}
+		if groupIDs[g.ID] {
+			return fmt.Errorf("duplicate group id %s", g.ID)
+		}
+		groupIDs[g.ID] = true
+		if err := validateBounds(g.Bounds); err != nil {
+			return fmt.Errorf("group %s: %v", g.ID, err)
+		}
+		if err := validateColor(g.Color); err != nil {
+			return fmt.Errorf("group %s: %v", g.ID, err)
+		}
+		for _, t := range g.Tables {
+			if t == "" {
+				return fmt.Errorf("group %s: empty table name in members", g.ID)
+			}
+		}
+	}
+
+	noteIDs := make(map[string]bool)
+	for _, n := range l.Notes {
+		if n.ID == "" {
+			return fmt.Errorf("note id is required")
+		}
+		if noteIDs[n.ID] {
+			return fmt.Errorf("duplicate note id %s", n.ID)
+		}
+		noteIDs[n.ID] = true
+		if len(n.Text) > maxNoteLength {
+			return fmt.Errorf("note %s: text longer than %d characters", n.ID, maxNoteLength)
+		}
+		if err := validateBounds(n.Bounds); err != nil {
+			return fmt.Errorf("note %s: %v", n.ID, err)
+		}
+		if err := validateColor(n.Color); err != nil {
+			return fmt.Errorf("note %s: %v", n.ID, err)
+		}
+	}
+
+	for _, e := range l.Edges {
+		if e.ID == "" {
+			return fmt.Errorf("edge id is required")
+		}
+		if len(e.Points) > maxEdgePoints {
+			return fmt.Errorf("edge %s: too many routing points (max %d)", e.ID, maxEdgePoints)
+		}
+		for _, p := range e.Points {
+			if !finite(p.X, p.Y) {
+				return fmt.Errorf("edge %s: routing points must be finite numbers", e.ID)
+			}
+		}
+	}
+	return nil
+}
+
+func validateBounds(b Bounds) error {
+	if !finite(b.X, b.Y, b.Width, b.Height) {
+		return fmt.Errorf("bounds must be finite numbers")
+	}
+	if b.Width < 0 || b.Height < 0 {
+		return fmt.Errorf("width and height cannot be negative")
+	}
+	return nil
+}
+
+func validateColor(c string) error {
+	if c != "" && !colorPattern.MatchString(c) {
+		return fmt.Errorf("invalid color %q", c)
+	}
+	return nil
+}
+
+func finite(values ...float64) bool {
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
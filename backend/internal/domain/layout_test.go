@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeLayout(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		nodes map[string]NodeLayout
+		err   bool
+	}{
+		{"empty", `{}`, map[string]NodeLayout{}, false},
+		{"nodes", `{"nodes": {"users": {"x": 1, "y": 2, "width": 200}}, "groups": []}`,
+			map[string]NodeLayout{"users": {X: 1, Y: 2, Width: 200}}, false},
+		{"legacy positions", `{"users": {"x": 1, "y": 2}, "orders": {"x": 3.5, "y": -4}}`,
+			map[string]NodeLayout{"users": {X: 1, Y: 2}, "orders": {X: 3.5, Y: -4}}, false},
+		{"legacy table named nodes", `{"nodes": {"x": 1, "y": 2}}`,
+			map[string]NodeLayout{"nodes": {X: 1, Y: 2}}, false},
+		{"unknown field", `{"users": {"x": 1}}`, nil, true},
+		{"legacy with extra field", `{"users": {"x": 1, "y": 2, "z": 3}}`, nil, true},
+		{"misspelled field", `{"node": {}}`, nil, true},
+		{"not an object", `[]`, nil, true},
+		{"not json", `{`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := DecodeLayout([]byte(tt.body))
+			if tt.err {
+				if err == nil {
+					t.Fatalf("DecodeLayout(%s) = %+v, want an error", tt.body, l)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeLayout(%s): %v", tt.body, err)
+			}
+			if !reflect.DeepEqual(l.Nodes, tt.nodes) {
+				t.Errorf("nodes = %+v, want %+v", l.Nodes, tt.nodes)
+			}
+			if l.Groups == nil || l.Notes == nil || l.Edges == nil {
+				t.Errorf("collections not initialized: %+v", l)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	maxQueryNameLength = 100
+	maxQueryTags       = 20
+	maxQueryParameters = 50
+)
+
+// Parameter types of saved queries
+const (
+	ParamString   = "string"
+	ParamInt      = "int"
+	ParamNumber   = "number"
+	ParamBool     = "bool"
+	ParamDate     = "date"
+	ParamDatetime = "datetime"
+)
+
+var paramNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// HistoryEntry records one run of the SQL console. RowCount sums the rows
+// returned or affected by its statements.
+type HistoryEntry struct {
+	ID         string    `json:"id"`
+	Database   string    `json:"database,omitempty"`
+	Query      string    `json:"query"`
+	DurationMs float64   `json:"duration_ms"`
+	RowCount   int64     `json:"row_count"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	ExecutedAt time.Time `json:"executed_at"`
+}
+
+// HistoryQuery searches the history, newest first. Search matches the query
+// text case-insensitively; Status is "ok", "error" or empty for both.
+type HistoryQuery struct {
+	Database string
+	Search   string
+	Status   string
+	Limit    int
+	Offset   int
+}
+
+type HistoryPage struct {
+	Entries []HistoryEntry `json:"entries"`
+	Total   int            `json:"total"`
+}
+
+// SavedQuery is a console query kept under a name. Parameters declare the
+// :name placeholders it uses.
+type SavedQuery struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Query       string           `json:"query"`
+	Database    string           `json:"database,omitempty"`
+	Tags        []string         `json:"tags,omitempty"`
+	Parameters  []QueryParameter `json:"parameters,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// QueryParameter declares a parameter of a saved query. Type is one of the
+// Param* constants; Default is used when a run leaves the parameter out.
+type QueryParameter struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Required    bool        `json:"required,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+	Description string      `json:"description,omitempty"`
+}
+
+// Validate checks a saved query coming from a client and normalizes its
+// tags (trimmed, lower case, without duplicates)
+func (q *SavedQuery) Validate() error {
+	q.Name = strings.TrimSpace(q.Name)
+	if q.Name == "" {
+		return fmt.Errorf("query name is required")
+	}
+	if len(q.Name) > maxQueryNameLength || strings.ContainsAny(q.Name, "/?#") {
+		return fmt.Errorf("query name must be at most %d characters without / ? #", maxQueryNameLength)
+	}
+	if strings.TrimSpace(q.Query) == "" {
+		return fmt.Errorf("query %s: query text is required", q.Name)
+	}
+
+	tags := make([]string, 0, len(q.Tags))
+	seen := map[string]bool{}
+	for _, t := range q.Tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t != "" && !seen[t] {
+			seen[t] = true
+			tags = append(tags, t)
+		}
+	}
+	if len(tags) > maxQueryTags {
+		return fmt.Errorf("query %s: at most %d tags", q.Name, maxQueryTags)
+	}
+	q.Tags = tags
+
+	if len(q.Parameters) > maxQueryParameters {
+		return fmt.Errorf("query %s: at most %d parameters", q.Name, maxQueryParameters)
+	}
+	names := map[string]bool{}
+	for i, p := range q.Parameters {
+		if !paramNamePattern.MatchString(p.Name) {
+			return fmt.Errorf("query %s: invalid parameter name %q", q.Name, p.Name)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("query %s: parameter %s is declared twice", q.Name, p.Name)
+		}
+		names[p.Name] = true
+		switch p.Type {
+		case ParamString, ParamInt, ParamNumber, ParamBool, ParamDate, ParamDatetime:
+		case "":
+			q.Parameters[i].Type = ParamString
+		default:
+			return fmt.Errorf("query %s: parameter %s has unknown type %q (use string, int, number, bool, date or datetime)", q.Name, p.Name, p.Type)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"fmt"
+	"path"
+)
+
+const maxViewHops = 10
+
+// Validate checks a view definition coming from a client
+func (v *DiagramView) Validate() error {
+	if v.Name == "" {
+		return fmt.Errorf("view name is required")
+	}
+	if v.Name == DefaultLayoutName {
+		return fmt.Errorf("%q is reserved for the full diagram", DefaultLayoutName)
+	}
+	if len(v.Tables) == 0 && len(v.Patterns) == 0 && v.Around == nil {
+		return fmt.Errorf("view %s selects no tables: set tables, patterns or around", v.Name)
+	}
+	for _, p := range v.Patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("view %s: invalid pattern %q", v.Name, p)
+		}
+	}
+	if v.Around != nil {
+		if v.Around.Table == "" {
+			return fmt.Errorf("view %s: around.table is required", v.Name)
+		}
+		if v.Around.Hops < 0 || v.Around.Hops > maxViewHops {
+			return fmt.Errorf("view %s: around.hops must be between 0 and %d", v.Name, maxViewHops)
+		}
+	}
+	return nil
+}
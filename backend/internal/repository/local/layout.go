@@ -0,0 +1,127 @@
+package local
+
+import (
+	"backend/internal/domain"
+	"context"
+	"sort"
+)
+
+const layoutsFile = "layouts.json"
+
+type layoutEntry struct {
+	Connection string         `json:"connection"`
+	Database   string         `json:"database"`
+	Name       string         `json:"name"`
+	Layout     *domain.Layout `json:"layout,omitempty"`
+
+	// Positions is the x/y-only format written before layouts were typed
+	Positions map[string]domain.NodeLayout `json:"positions,omitempty"`
+}
+
+func (e layoutEntry) layout() *domain.Layout {
+	if e.Layout != nil {
+		e.Layout.Normalize()
+		return e.Layout
+	}
+	l := domain.NewLayout()
+	for name, n := range e.Positions {
+		l.Nodes[name] = n
+	}
+	return l
+}
+
+func (e layoutEntry) matches(key domain.LayoutKey) bool {
+	name := e.Name
+	if name == "" {
+		name = domain.DefaultLayoutName
+	}
+	return e.Connection == key.Connection && e.Database == key.Database && name == key.Name
+}
+
+type layoutRepository struct {
+	file *jsonFile
+}
+
+// NewLayoutRepository stores diagram layouts in dir/layouts.json
+func NewLayoutRepository(dir string) domain.LayoutRepository {
+	return &layoutRepository{file: newJSONFile(dir, layoutsFile)}
+}
+
+func (r *layoutRepository) Get(ctx context.Context, key domain.LayoutKey) (*domain.Layout, bool, error) {
+	r.file.mu.Lock()
+	defer r.file.mu.Unlock()
+
+	var entries []layoutEntry
+	if err := r.file.load(&entries); err != nil {
+		return nil, false, err
+	}
+	for _, e := range entries {
+		if e.matches(key) {
+			return e.layout(), true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (r *layoutRepository) Save(ctx context.Context, key domain.LayoutKey, layout *domain.Layout) error {
+	r.file.mu.Lock()
+	defer r.file.mu.Unlock()
+
+	var entries []layoutEntry
+	if err := r.file.load(&entries); err != nil {
+		return err
+	}
+
+	entry := layoutEntry{Connection: key.Connection, Database: key.Database, Name: key.Name, Layout: layout}
+	found := false
+	for i, e := range entries {
+		if e.matches(key) {
+			entries[i] = entry
+			found = true
+			break
+		}
+	}
+	if !found {
+		entries = append(entries, entry)
+	}
+	return r.file.save(entries)
+}
+
+func (r *layoutRepository) Delete(ctx context.Context, key domain.LayoutKey) error {
+	r.file.mu.Lock()
+	defer r.file.mu.Unlock()
+
+	var entries []layoutEntry
+	if err := r.file.load(&entries); err != nil {
+		return err
+	}
+	filtered := make([]layoutEntry, 0, len(entries))
+	for _, e := range entries {
+		if !e.matches(key) {
+			filtered = append(filtered, e)
+		}
+	}
+	return r.file.save(filtered)
+}
+
+func (r *layoutRepository) List(ctx context.Context, connection, database string) ([]string, error) {
+	r.file.mu.Lock()
+	defer r.file.mu.Unlock()
+
+	var entries []layoutEntry
+	if err := r.file.load(&entries); err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, e := range entries {
+		if e.Connection == connection && e.Database == database {
+			name := e.Name
+			if name == "" {
+				name = domain.DefaultLayoutName
+			}
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,278 @@
+package local
+
+import (
+	"backend/internal/domain"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	historyFile      = "history.jsonl"
+	legacyHistory    = "history.json"
+	savedQueriesFile = "queries.json"
+	// maxHistory is how many console runs are kept per connection; older
+	// entries are dropped
+	maxHistory = 2000
+	// maxHistoryBytes caps the history file across all connections. Once an
+	// append crosses it the file is compacted to half, oldest entries first.
+	maxHistoryBytes = 32 << 20
+)
+
+type historyEntry struct {
+	Connection string `json:"connection"`
+	domain.HistoryEntry
+}
+
+type savedQueryEntry struct {
+	Connection string            `json:"connection"`
+	Query      domain.SavedQuery `json:"query"`
+}
+
+type queryRepository struct {
+	history *jsonLines
+	saved   *jsonFile
+}
+
+// NewQueryRepository appends the console history to dir/history.jsonl and
+// stores saved queries in dir/queries.json
+func NewQueryRepository(dir string) domain.QueryRepository {
+	r := &queryRepository{
+		history: newJSONLines(dir, historyFile),
+		saved:   newJSONFile(dir, savedQueriesFile),
+	}
+	if err := r.migrateHistory(newJSONFile(dir, legacyHistory)); err != nil {
+		log.Printf("Failed to migrate %s: %v", legacyHistory, err)
+	}
+	return r
+}
+
+// migrateHistory moves the entries of the old single-document history into
+// the JSONL file, once
+func (r *queryRepository) migrateHistory(legacy *jsonFile) error {
+	if _, err := os.Stat(legacy.path); err != nil {
+		return nil
+	}
+	var entries []historyEntry
+	if err := legacy.load(&entries); err != nil {
+		return err
+	}
+	lines := make([][]byte, 0, len(entries))
+	for _, e := range entries {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, data)
+	}
+	if err := r.history.rewrite(lines); err != nil {
+		return err
+	}
+	return os.Remove(legacy.path)
+}
+
+func (r *queryRepository) AddHistory(ctx context.Context, connection string, entry domain.HistoryEntry) error {
+	r.history.mu.Lock()
+	defer r.history.mu.Unlock()
+
+	size, err := r.history.append(historyEntry{Connection: connection, HistoryEntry: entry})
+	if err != nil || size <= maxHistoryBytes {
+		return err
+	}
+	return r.compactHistory()
+}
+
+// compactHistory rewrites the history with the newest entries that fit in
+// half of maxHistoryBytes, at most maxHistory per connection. Lines are
+// copied as they are; only their connection is decoded.
+func (r *queryRepository) compactHistory() error {
+	type line struct {
+		connection string
+		data       []byte
+	}
+	var lines []line
+	err := r.history.each(func(data []byte) error {
+		var e struct {
+			Connection string `json:"connection"`
+		}
+		if json.Unmarshal(data, &e) != nil {
+			return nil
+		}
+		lines = append(lines, line{e.Connection, append([]byte(nil), data...)})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+	size := 0
+	first := len(lines)
+	kept := 0
+	for i := len(lines) - 1; i >= 0; i-- {
+		size += len(lines[i].data) + 1
+		if size > maxHistoryBytes/2 {
+			break
+		}
+		first = i
+		counts[lines[i].connection]++
+		if counts[lines[i].connection] <= maxHistory {
+			kept++
+		}
+	}
+	out := make([][]byte, 0, kept)
+	for i := first; i < len(lines); i++ {
+		c := lines[i].connection
+		// counts holds what is left of each connection from here on
+		if counts[c] > maxHistory {
+			counts[c]--
+			continue
+		}
+		out = append(out, lines[i].data)
+	}
+	return r.history.rewrite(out)
+}
+
+// loadHistory reads the entries of connection, oldest first, trimmed to
+// the newest maxHistory
+func (r *queryRepository) loadHistory(connection string) ([]domain.HistoryEntry, error) {
+	var entries []domain.HistoryEntry
+	err := r.history.each(func(data []byte) error {
+		var e historyEntry
+		if json.Unmarshal(data, &e) == nil && e.Connection == connection {
+			entries = append(entries, e.HistoryEntry)
+		}
+		return nil
+	})
+	if len(entries) > maxHistory {
+		entries = entries[len(entries)-maxHistory:]
+	}
+	return entries, err
+}
+
+func (r *queryRepository) SearchHistory(ctx context.Context, connection string, q domain.HistoryQuery) (*domain.HistoryPage, error) {
+	r.history.mu.Lock()
+	defer r.history.mu.Unlock()
+
+	entries, err := r.loadHistory(connection)
+	if err != nil {
+		return nil, err
+	}
+	search := strings.ToLower(q.Search)
+	page := &domain.HistoryPage{Entries: []domain.HistoryEntry{}}
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if q.Database != "" && e.Database != q.Database {
+			continue
+		}
+		if q.Status == "ok" && !e.Success || q.Status == "error" && e.Success {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(e.Query), search) {
+			continue
+		}
+		if page.Total >= q.Offset && (q.Limit <= 0 || len(page.Entries) < q.Limit) {
+			page.Entries = append(page.Entries, e)
+		}
+		page.Total++
+	}
+	return page, nil
+}
+
+func (r *queryRepository) ClearHistory(ctx context.Context, connection string) error {
+	r.history.mu.Lock()
+	defer r.history.mu.Unlock()
+
+	var kept [][]byte
+	err := r.history.each(func(data []byte) error {
+		var e struct {
+			Connection string `json:"connection"`
+		}
+		if json.Unmarshal(data, &e) == nil && e.Connection != connection {
+			kept = append(kept, append([]byte(nil), data...))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return r.history.rewrite(kept)
+}
+
+func (r *queryRepository) ListSaved(ctx context.Context, connection string) ([]domain.SavedQuery, error) {
+	r.saved.mu.Lock()
+	defer r.saved.mu.Unlock()
+
+	var entries []savedQueryEntry
+	if err := r.saved.load(&entries); err != nil {
+		return nil, err
+	}
+	queries := []domain.SavedQuery{}
+	for _, e := range entries {
+		if e.Connection == connection {
+			queries = append(queries, e.Query)
+		}
+	}
+	sort.Slice(queries, func(i, j int) bool { return queries[i].Name < queries[j].Name })
+	return queries, nil
+}
+
+func (r *queryRepository) GetSaved(ctx context.Context, connection, name string) (*domain.SavedQuery, bool, error) {
+	r.saved.mu.Lock()
+	defer r.saved.mu.Unlock()
+
+	var entries []savedQueryEntry
+	if err := r.saved.load(&entries); err != nil {
+		return nil, false, err
+	}
+	for _, e := range entries {
+		if e.Connection == connection && e.Query.Name == name {
+			q := e.Query
+			return &q, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (r *queryRepository) SaveQuery(ctx context.Context, connection string, q domain.SavedQuery) error {
+	r.saved.mu.Lock()
+	defer r.saved.mu.Unlock()
+
+	var entries []savedQueryEntry
+	if err := r.saved.load(&entries); err != nil {
+		return err
+	}
+	entry := savedQueryEntry{Connection: connection, Query: q}
+	found := false
+	for i, e := range entries {
+		if e.Connection == connection && e.Query.Name == q.Name {
+			entries[i] = entry
+			found = true
+			break
+		}
+	}
+	if !found {
+		entries = append(entries, entry)
+	}
+	return r.saved.save(entries)
+}
+
+func (r *queryRepository) DeleteSaved(ctx context.Context, connection, name string) error {
+	r.saved.mu.Lock()
+	defer r.saved.mu.Unlock()
+
+	var entries []savedQueryEntry
+	if err := r.saved.load(&entries); err != nil {
+		return err
+	}
+	filtered := make([]savedQueryEntry, 0, len(entries))
+	for _, e := range entries {
+		if !(e.Connection == connection && e.Query.Name == name) {
+			filtered = append(filtered, e)
+		}
+	}
+	return r.saved.save(filtered)
+}
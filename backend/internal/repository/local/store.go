@@ -0,0 +1,135 @@
+package local
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// jsonFile is a JSON document on disk used as a tiny metadata store.
+// Everything the tool needs to remember about a database lives here instead
+// of inside the user's schema.
+type jsonFile struct {
+	mu   sync.Mutex
+	path string
+}
+
+func newJSONFile(dir, name string) *jsonFile {
+	return &jsonFile{path: filepath.Join(dir, name)}
+}
+
+// load decodes the file into v. A missing file leaves v untouched.
+func (f *jsonFile) load(v interface{}) error {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// save writes v atomically (temp file + rename) so a crash never leaves
+// a half written document behind.
+func (f *jsonFile) save(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
+
+// jsonLines is an append-only file of JSON documents, one per line. Adding
+// a record costs one small write instead of rewriting the whole document.
+type jsonLines struct {
+	mu   sync.Mutex
+	path string
+}
+
+func newJSONLines(dir, name string) *jsonLines {
+	return &jsonLines{path: filepath.Join(dir, name)}
+}
+
+// append writes v as one line and returns the size of the file after it
+func (f *jsonLines) append(v interface{}) (int64, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return 0, err
+	}
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// each calls fn with every line, oldest first. A missing file has no lines;
+// a torn last line (crash while appending) is skipped.
+func (f *jsonLines) each(fn func(line []byte) error) error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+	r := bufio.NewReader(file)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			if err := fn(line[:len(line)-1]); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// rewrite replaces the file with lines atomically
+func (f *jsonLines) rewrite(lines [][]byte) error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
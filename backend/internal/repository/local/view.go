@@ -0,0 +1,103 @@
+package local
+
+import (
+	"backend/internal/domain"
+	"context"
+	"sort"
+)
+
+const viewsFile = "views.json"
+
+type viewEntry struct {
+	Connection string             `json:"connection"`
+	Database   string             `json:"database"`
+	View       domain.DiagramView `json:"view"`
+}
+
+func (e viewEntry) matches(connection, database, name string) bool {
+	return e.Connection == connection && e.Database == database && e.View.Name == name
+}
+
+type viewRepository struct {
+	file *jsonFile
+}
+
+// NewViewRepository stores diagram views in dir/views.json
+func NewViewRepository(dir string) domain.ViewRepository {
+	return &viewRepository{file: newJSONFile(dir, viewsFile)}
+}
+
+func (r *viewRepository) List(ctx context.Context, connection, database string) ([]domain.DiagramView, error) {
+	r.file.mu.Lock()
+	defer r.file.mu.Unlock()
+
+	var entries []viewEntry
+	if err := r.file.load(&entries); err != nil {
+		return nil, err
+	}
+	views := []domain.DiagramView{}
+	for _, e := range entries {
+		if e.Connection == connection && e.Database == database {
+			views = append(views, e.View)
+		}
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].Name < views[j].Name })
+	return views, nil
+}
+
+func (r *viewRepository) Get(ctx context.Context, connection, database, name string) (*domain.DiagramView, bool, error) {
+	r.file.mu.Lock()
+	defer r.file.mu.Unlock()
+
+	var entries []viewEntry
+	if err := r.file.load(&entries); err != nil {
+		return nil, false, err
+	}
+	for _, e := range entries {
+		if e.matches(connection, database, name) {
+			view := e.View
+			return &view, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (r *viewRepository) Save(ctx context.Context, connection, database string, view domain.DiagramView) error {
+	r.file.mu.Lock()
+	defer r.file.mu.Unlock()
+
+	var entries []viewEntry
+	if err := r.file.load(&entries); err != nil {
+		return err
+	}
+	entry := viewEntry{Connection: connection, Database: database, View: view}
+	found := false
+	for i, e := range entries {
+		if e.matches(connection, database, view.Name) {
+			entries[i] = entry
+			found = true
+			break
+		}
+	}
+	if !found {
+		entries = append(entries, entry)
+	}
+	return r.file.save(entries)
+}
+
+func (r *viewRepository) Delete(ctx context.Context, connection, database, name string) error {
+	r.file.mu.Lock()
+	defer r.file.mu.Unlock()
+
+	var entries []viewEntry
+	if err := r.file.load(&entries); err != nil {
+		return err
+	}
+	filtered := make([]viewEntry, 0, len(entries))
+	for _, e := range entries {
+		if !e.matches(connection, database, name) {
+			filtered = append(filtered, e)
+		}
+	}
+	return r.file.save(filtered)
+}
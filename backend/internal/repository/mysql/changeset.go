@@ -0,0 +1,133 @@
+package mysql
+
+import (
+	"backend/internal/domain"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ApplyWrites executes a changeset inside a single transaction. Results are
+// reported per operation; the first failure stops the run, marks the rest
+// as skipped and rolls everything back.
+func (r *mysqlRepository) ApplyWrites(ctx context.Context, writes []domain.RowWrite) (*domain.ChangesetResult, error) {
+	db, err := r.writableDB()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.ChangesetResult{Results: make([]domain.OperationResult, len(writes))}
+	for i, w := range writes {
+		result.Results[i] = domain.OperationResult{Index: i, Type: w.Type, Table: w.Table, Status: "skipped"}
+	}
+
+	var opErr error
+	txErr := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		columns := make(map[string]map[string]bool)
+		for i, w := range writes {
+			res := &result.Results[i]
+			if err := r.applyWrite(tx, w, result.Results[:i], columns, res); err != nil {
+				res.Status = "failed"
+				res.Error = err.Error()
+				opErr = fmt.Errorf("operation %d (%s %s): %w", i, w.Type, w.Table, err)
+				return opErr
+			}
+			res.Status = "ok"
+		}
+		return nil
+	})
+	if txErr != nil {
+		if opErr == nil {
+			// commit itself failed
+			return nil, txErr
+		}
+		return result, opErr
+	}
+	result.Committed = true
+	return result, nil
+}
+
+func (r *mysqlRepository) applyWrite(tx *gorm.DB, w domain.RowWrite, done []domain.OperationResult, columns map[string]map[string]bool, res *domain.OperationResult) error {
+	cols, ok := columns[w.Table]
+	if !ok {
+		info, err := r.tableColumns(tx, w.Table)
+		if err != nil {
+			return err
+		}
+		cols = columnSet(info)
+		columns[w.Table] = cols
+	}
+
+	values, err := resolveRefs(w.Values, done)
+	if err != nil {
+		return err
+	}
+	cond, err := resolveRefs(w.Condition, done)
+	if err != nil {
+		return err
+	}
+	if err := checkColumns(values, cols); err != nil {
+		return err
+	}
+	if err := checkColumns(cond, cols); err != nil {
+		return err
+	}
+
+	switch w.Type {
+	case domain.ChangeInsert:
+		id, err := insertRow(tx, w.Table, values)
+		if err != nil {
+			return err
+		}
+		res.Affected, res.InsertID = 1, id
+		return nil
+	case domain.ChangeUpdate:
+		// MySQL reports 0 affected rows when nothing changed, so check the
+		// row still exists rather than trusting the count.
+		where, args := matchClause(cond)
+		var n int64
+		if err := tx.Raw(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", quoteIdent(w.Table), where), args...).Scan(&n).Error; err != nil {
+			return err
+		}
+		if n == 0 {
+			return errors.New("row not found (changed or deleted by someone else?)")
+		}
+		res.Affected, err = updateRow(tx, w.Table, cond, values)
+		return err
+	case domain.ChangeDelete:
+		res.Affected, err = deleteRow(tx, w.Table, cond)
+		if err == nil && res.Affected == 0 {
+			return errors.New("row not found (changed or deleted by someone else?)")
+		}
+		return err
+	}
+	return fmt.Errorf("unknown operation type %q: %w", w.Type, domain.ErrInvalidInput)
+}
+
+// resolveRefs replaces {"$ref": n} values with the insert id of operation n
+func resolveRefs(m map[string]interface{}, done []domain.OperationResult) (map[string]interface{}, error) {
+	if len(m) == 0 {
+		return m, nil
+	}
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		ref, ok := v.(map[string]interface{})
+		if !ok {
+			out[k] = v
+			continue
+		}
+		idx, ok := ref["$ref"].(json.Number)
+		n, err := idx.Int64()
+		if !ok || err != nil || len(ref) != 1 {
+			return nil, fmt.Errorf("column %q: objects are only allowed as {\"$ref\": n}: %w", k, domain.ErrInvalidInput)
+		}
+		if n < 0 || n >= int64(len(done)) || done[n].Type != domain.ChangeInsert {
+			return nil, fmt.Errorf("column %q: $ref %d must point to an earlier insert: %w", k, n, domain.ErrInvalidInput)
+		}
+		out[k] = done[n].InsertID
+	}
+	return out, nil
+}
@@ -0,0 +1,153 @@
+package mysql
+
+import (
+	"backend/internal/domain"
+	"context"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// GetConstraints reads the foreign keys and unique indexes of a table in
+// the current database
+func (r *mysqlRepository) GetConstraints(ctx context.Context, tableName string) (*domain.TableConstraints, error) {
+	db, err := r.getDB()
+	if err != nil {
+		return nil, err
+	}
+	tx := db.WithContext(ctx)
+	if _, err := r.tableColumns(tx, tableName); err != nil {
+		return nil, err
+	}
+
+	fks, err := foreignKeys(tx, "TABLE_NAME = ?", tableName)
+	if err != nil {
+		return nil, err
+	}
+	c := &domain.TableConstraints{ForeignKeys: fks, UniqueKeys: [][]string{}}
+
+	var indexRows []struct {
+		KeyName    string `gorm:"column:Key_name"`
+		NonUnique  int    `gorm:"column:Non_unique"`
+		ColumnName string `gorm:"column:Column_name"`
+	}
+	if err := tx.Raw("SHOW INDEX FROM " + quoteIdent(tableName)).Scan(&indexRows).Error; err != nil {
+		return nil, err
+	}
+	// SHOW INDEX lists the parts of each index consecutively
+	var names []string
+	parts := make(map[string][]string)
+	functional := make(map[string]bool)
+	for _, row := range indexRows {
+		if row.NonUnique != 0 {
+			continue
+		}
+		if _, ok := parts[row.KeyName]; !ok {
+			names = append(names, row.KeyName)
+		}
+		// functional key parts have no column and cannot be generated for
+		if row.ColumnName == "" {
+			functional[row.KeyName] = true
+		}
+		parts[row.KeyName] = append(parts[row.KeyName], row.ColumnName)
+	}
+	for _, name := range names {
+		if !functional[name] {
+			c.UniqueKeys = append(c.UniqueKeys, parts[name])
+		}
+	}
+	return c, nil
+}
+
+// GetReferencingKeys returns the foreign keys, in any table of the current
+// database, that reference tableName
+func (r *mysqlRepository) GetReferencingKeys(ctx context.Context, tableName string) ([]domain.ForeignKey, error) {
+	db, err := r.getDB()
+	if err != nil {
+		return nil, err
+	}
+	tx := db.WithContext(ctx)
+	if _, err := r.tableColumns(tx, tableName); err != nil {
+		return nil, err
+	}
+	return foreignKeys(tx, "REFERENCED_TABLE_NAME = ?", tableName)
+}
+
+// foreignKeys reads KEY_COLUMN_USAGE rows matching cond and groups the
+// columns of composite keys
+func foreignKeys(tx *gorm.DB, cond string, args ...interface{}) ([]domain.ForeignKey, error) {
+	var rows []struct {
+		ConstraintName string `gorm:"column:CONSTRAINT_NAME"`
+		TableName      string `gorm:"column:TABLE_NAME"`
+		ColumnName     string `gorm:"column:COLUMN_NAME"`
+		RefTableName   string `gorm:"column:REFERENCED_TABLE_NAME"`
+		RefColumnName  string `gorm:"column:REFERENCED_COLUMN_NAME"`
+	}
+	err := tx.Raw(`
+		SELECT CONSTRAINT_NAME, TABLE_NAME, COLUMN_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME
+		FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = DATABASE() AND REFERENCED_TABLE_NAME IS NOT NULL AND `+cond+`
+		ORDER BY TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION`, args...).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	fks := []domain.ForeignKey{}
+	for _, row := range rows {
+		n := len(fks)
+		if n == 0 || fks[n-1].Name != row.ConstraintName || fks[n-1].Table != row.TableName {
+			fks = append(fks, domain.ForeignKey{Name: row.ConstraintName, Table: row.TableName, RefTable: row.RefTableName})
+			n++
+		}
+		fk := &fks[n-1]
+		fk.Columns = append(fk.Columns, row.ColumnName)
+		fk.RefColumns = append(fk.RefColumns, row.RefColumnName)
+	}
+	return fks, nil
+}
+
+// SampleRows returns distinct tuples of existing values, e.g. parent keys
+// to point new child rows at
+func (r *mysqlRepository) SampleRows(ctx context.Context, tableName string, columns []string, limit int) ([][]interface{}, error) {
+	db, err := r.getDB()
+	if err != nil {
+		return nil, err
+	}
+	tx := db.WithContext(ctx)
+	cols, err := r.tableColumns(tx, tableName)
+	if err != nil {
+		return nil, err
+	}
+	known := columnSet(cols)
+	quoted := make([]string, len(columns))
+	notNull := make([]string, len(columns))
+	for i, c := range columns {
+		if !known[c] {
+			return nil, fmt.Errorf("unknown column %q: %w", c, domain.ErrInvalidInput)
+		}
+		quoted[i] = quoteIdent(c)
+		notNull[i] = quoted[i] + " IS NOT NULL"
+	}
+	query := "SELECT DISTINCT " + strings.Join(quoted, ", ") + " FROM " + quoteIdent(tableName) +
+		" WHERE " + strings.Join(notNull, " AND ") + " LIMIT ?"
+	rows, err := tx.Raw(query, limit).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out [][]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		out = append(out, values)
+	}
+	return out, rows.Err()
+}
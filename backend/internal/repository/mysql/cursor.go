@@ -0,0 +1,199 @@
+package mysql
+
+import (
+	"backend/internal/domain"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const cursorTimeFormat = "2006-01-02 15:04:05.999999999"
+
+// cursorToken is what the opaque cursor string decodes to: the sort key
+// (column list with directions) and the key values of the last row read.
+type cursorToken struct {
+	Key    string        `json:"k"`
+	Values []cursorValue `json:"v"`
+}
+
+// cursorValue keeps the Go type of a key value so it binds back exactly
+// (big integers as integers, times as DATETIME literals, bytes as bytes).
+type cursorValue struct {
+	Int   *string  `json:"i,omitempty"`
+	Float *float64 `json:"f,omitempty"`
+	Str   *string  `json:"s,omitempty"`
+	Bytes []byte   `json:"b,omitempty"`
+	Time  *string  `json:"t,omitempty"`
+	Null  bool     `json:"n,omitempty"`
+}
+
+// keysetColumns returns the columns a cursor orders by: the requested sort
+// followed by the primary key as a tie-breaker, so the order is total.
+func keysetColumns(sort []domain.SortField, cols []columnInfo) ([]domain.SortField, error) {
+	var pk []string
+	for _, c := range cols {
+		if c.Key == "PRI" {
+			pk = append(pk, c.Field)
+		}
+	}
+	if len(pk) == 0 {
+		return nil, fmt.Errorf("cursor pagination needs a primary key; use page/limit for this table: %w", domain.ErrInvalidInput)
+	}
+
+	keys := append([]domain.SortField(nil), sort...)
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		seen[k.Column] = true
+	}
+	for _, c := range pk {
+		if !seen[c] {
+			keys = append(keys, domain.SortField{Column: c})
+		}
+	}
+	return keys, nil
+}
+
+func keysetSignature(keys []domain.SortField) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k.Column
+		if k.Desc {
+			parts[i] = "-" + k.Column
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func encodeCursor(keys []domain.SortField, row map[string]interface{}) (string, error) {
+	tok := cursorToken{Key: keysetSignature(keys)}
+	for _, k := range keys {
+		v, err := toCursorValue(row[k.Column])
+		if err != nil {
+			return "", err
+		}
+		tok.Values = append(tok.Values, v)
+	}
+	data, err := json.Marshal(tok)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(cursor string, keys []domain.SortField) ([]interface{}, error) {
+	invalid := fmt.Errorf("invalid cursor: %w", domain.ErrInvalidInput)
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	var tok cursorToken
+	if err := json.Unmarshal(data, &tok); err != nil {
+		return nil, invalid
+	}
+	if tok.Key != keysetSignature(keys) || len(tok.Values) != len(keys) {
+		return nil, fmt.Errorf("cursor belongs to a different sort order; start again without a cursor: %w", domain.ErrInvalidInput)
+	}
+	values := make([]interface{}, len(tok.Values))
+	for i, v := range tok.Values {
+		if values[i], err = v.value(); err != nil {
+			return nil, invalid
+		}
+	}
+	return values, nil
+}
+
+func toCursorValue(v interface{}) (cursorValue, error) {
+	switch x := v.(type) {
+	case nil:
+		return cursorValue{Null: true}, nil
+	case int64:
+		s := strconv.FormatInt(x, 10)
+		return cursorValue{Int: &s}, nil
+	case int32, int16, int8, int, uint64, uint32, uint16, uint8, uint:
+		s := fmt.Sprint(x)
+		return cursorValue{Int: &s}, nil
+	case float64:
+		return cursorValue{Float: &x}, nil
+	case float32:
+		f := float64(x)
+		return cursorValue{Float: &f}, nil
+	case string:
+		return cursorValue{Str: &x}, nil
+	case []byte:
+		return cursorValue{Bytes: x}, nil
+	case time.Time:
+		s := x.Format(cursorTimeFormat)
+		return cursorValue{Time: &s}, nil
+	case bool:
+		s := "0"
+		if x {
+			s = "1"
+		}
+		return cursorValue{Int: &s}, nil
+	}
+	return cursorValue{}, fmt.Errorf("cannot build a cursor from a %T value", v)
+}
+
+func (v cursorValue) value() (interface{}, error) {
+	switch {
+	case v.Null:
+		return nil, nil
+	case v.Int != nil:
+		if n, err := strconv.ParseInt(*v.Int, 10, 64); err == nil {
+			return n, nil
+		}
+		return strconv.ParseUint(*v.Int, 10, 64)
+	case v.Float != nil:
+		return *v.Float, nil
+	case v.Str != nil:
+		return *v.Str, nil
+	case v.Time != nil:
+		// bound as a string literal, which MySQL compares as DATETIME
+		return *v.Time, nil
+	}
+	return v.Bytes, nil
+}
+
+// keysetAfter builds the condition "row comes after the cursor row" for a
+// multi-column order with mixed directions. MySQL sorts NULLs first in
+// ascending order and last in descending order; the expression follows that.
+func keysetAfter(keys []domain.SortField, values []interface{}) (string, []interface{}) {
+	var build func(i int) (string, []interface{})
+	build = func(i int) (string, []interface{}) {
+		col := quoteIdent(keys[i].Column)
+		v := values[i]
+
+		var gt string
+		var gtArgs []interface{}
+		switch {
+		case v == nil && !keys[i].Desc:
+			gt = col + " IS NOT NULL"
+		case v == nil && keys[i].Desc:
+			gt = ""
+		case !keys[i].Desc:
+			gt, gtArgs = col+" > ?", []interface{}{v}
+		default:
+			gt, gtArgs = "("+col+" < ? OR "+col+" IS NULL)", []interface{}{v}
+		}
+
+		if i == len(keys)-1 {
+			if gt == "" {
+				return "FALSE", nil
+			}
+			return gt, gtArgs
+		}
+
+		rest, restArgs := build(i + 1)
+		eq := col + " <=> ?"
+		tie := "(" + eq + " AND " + rest + ")"
+		tieArgs := append([]interface{}{v}, restArgs...)
+		if gt == "" {
+			return tie, tieArgs
+		}
+		return "(" + gt + " OR " + tie + ")", append(gtArgs, tieArgs...)
+	}
+	return build(0)
+}
@@ -0,0 +1,169 @@
+package mysql
+
+import (
+	"backend/internal/domain"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	keys := []domain.SortField{{Column: "v"}}
+	tests := []struct {
+		name string
+		in   interface{}
+		want interface{}
+	}{
+		{"null", nil, nil},
+		{"int64", int64(-42), int64(-42)},
+		{"int32", int32(7), int64(7)},
+		{"big uint64", uint64(18446744073709551615), uint64(18446744073709551615)},
+		{"float", 1.5, 1.5},
+		{"float32", float32(0.25), 0.25},
+		{"string", "a,b\"c", "a,b\"c"},
+		{"empty string", "", ""},
+		{"bytes", []byte{0, 1, 255}, []byte{0, 1, 255}},
+		{"time", time.Date(2024, 2, 29, 13, 4, 5, 123456000, time.UTC), "2024-02-29 13:04:05.123456"},
+		{"bool", true, int64(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := encodeCursor(keys, map[string]interface{}{"v": tt.in})
+			if err != nil {
+				t.Fatalf("encodeCursor: %v", err)
+			}
+			values, err := decodeCursor(cursor, keys)
+			if err != nil {
+				t.Fatalf("decodeCursor: %v", err)
+			}
+			if !reflect.DeepEqual(values, []interface{}{tt.want}) {
+				t.Errorf("decoded %#v, want %#v", values, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorErrors(t *testing.T) {
+	keys := []domain.SortField{{Column: "id"}}
+	valid, err := encodeCursor(keys, map[string]interface{}{"id": int64(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		cursor string
+		keys   []domain.SortField
+	}{
+		{"not base64", "!!!", keys},
+		{"not json", "bm90IGpzb24", keys},
+		{"other direction", valid, []domain.SortField{{Column: "id", Desc: true}}},
+		{"other column", valid, []domain.SortField{{Column: "name"}}},
+		{"more keys", valid, []domain.SortField{{Column: "id"}, {Column: "name"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.cursor, tt.keys); !errors.Is(err, domain.ErrInvalidInput) {
+				t.Errorf("error = %v, want ErrInvalidInput", err)
+			}
+		})
+	}
+}
+
+func TestEncodeCursorUnsupported(t *testing.T) {
+	keys := []domain.SortField{{Column: "v"}}
+	if _, err := encodeCursor(keys, map[string]interface{}{"v": struct{}{}}); err == nil {
+		t.Error("encodeCursor accepted a struct value")
+	}
+}
+
+func TestKeysetColumns(t *testing.T) {
+	cols := []columnInfo{{Field: "a", Key: "PRI"}, {Field: "b", Key: "PRI"}, {Field: "name"}}
+	tests := []struct {
+		name string
+		sort []domain.SortField
+		want []domain.SortField
+	}{
+		{"primary key only", nil, []domain.SortField{{Column: "a"}, {Column: "b"}}},
+		{"sort then key", []domain.SortField{{Column: "name", Desc: true}},
+			[]domain.SortField{{Column: "name", Desc: true}, {Column: "a"}, {Column: "b"}}},
+		{"key column already sorted", []domain.SortField{{Column: "b", Desc: true}},
+			[]domain.SortField{{Column: "b", Desc: true}, {Column: "a"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := keysetColumns(tt.sort, cols)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keysetColumns = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if _, err := keysetColumns(nil, []columnInfo{{Field: "name"}}); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("no primary key error = %v, want ErrInvalidInput", err)
+	}
+}
+
+func TestKeysetAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		keys   []domain.SortField
+		values []interface{}
+		sql    string
+		args   []interface{}
+	}{
+		{
+			name:   "ascending",
+			keys:   []domain.SortField{{Column: "id"}},
+			values: []interface{}{int64(5)},
+			sql:    "`id` > ?",
+			args:   []interface{}{int64(5)},
+		},
+		{
+			name:   "descending includes nulls",
+			keys:   []domain.SortField{{Column: "id", Desc: true}},
+			values: []interface{}{int64(5)},
+			sql:    "(`id` < ? OR `id` IS NULL)",
+			args:   []interface{}{int64(5)},
+		},
+		{
+			name:   "ascending after null",
+			keys:   []domain.SortField{{Column: "n"}},
+			values: []interface{}{nil},
+			sql:    "`n` IS NOT NULL",
+		},
+		{
+			name:   "descending after null is the end",
+			keys:   []domain.SortField{{Column: "n", Desc: true}},
+			values: []interface{}{nil},
+			sql:    "FALSE",
+		},
+		{
+			name:   "tie broken by key",
+			keys:   []domain.SortField{{Column: "name", Desc: true}, {Column: "id"}},
+			values: []interface{}{"x", int64(5)},
+			sql:    "((`name` < ? OR `name` IS NULL) OR (`name` <=> ? AND `id` > ?))",
+			args:   []interface{}{"x", "x", int64(5)},
+		},
+		{
+			name:   "null sort value then key",
+			keys:   []domain.SortField{{Column: "n", Desc: true}, {Column: "id"}},
+			values: []interface{}{nil, int64(5)},
+			sql:    "(`n` <=> ? AND `id` > ?)",
+			args:   []interface{}{nil, int64(5)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := keysetAfter(tt.keys, tt.values)
+			if sql != tt.sql {
+				t.Errorf("sql = %q, want %q", sql, tt.sql)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
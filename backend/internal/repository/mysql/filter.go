@@ -0,0 +1,178 @@
+package mysql
+
+import (
+	"backend/internal/domain"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const maxFilterDepth = 8
+
+// quoteIdent quotes a table or column name for MySQL
+func quoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// sqlArg prepares a value decoded from client JSON for binding. Handlers
+// decode numbers as json.Number so big integers stay exact; integers are
+// bound as int64 and other numbers as their decimal text.
+func sqlArg(v interface{}) interface{} {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v
+	}
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	return n.String()
+}
+
+// sqlArgs applies sqlArg to every value
+func sqlArgs(values []interface{}) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = sqlArg(v)
+	}
+	return out
+}
+
+// buildWhere turns a filter tree into a parameterized WHERE clause (without
+// the WHERE keyword). Column names are checked against columns, so only
+// values ever reach the query as arguments.
+func buildWhere(g *domain.FilterGroup, columns map[string]bool) (string, []interface{}, error) {
+	if g == nil {
+		return "", nil, nil
+	}
+	return buildGroup(g, columns, 0)
+}
+
+func buildGroup(g *domain.FilterGroup, columns map[string]bool, depth int) (string, []interface{}, error) {
+	if depth > maxFilterDepth {
+		return "", nil, fmt.Errorf("filter nested too deeply: %w", domain.ErrInvalidInput)
+	}
+
+	joiner := " AND "
+	switch strings.ToLower(g.Logic) {
+	case "", "and":
+	case "or":
+		joiner = " OR "
+	default:
+		return "", nil, fmt.Errorf("unknown filter logic %q: %w", g.Logic, domain.ErrInvalidInput)
+	}
+
+	var parts []string
+	var args []interface{}
+	for _, c := range g.Conditions {
+		sql, a, err := buildCondition(c, columns)
+		if err != nil {
+			return "", nil, err
+		}
+		parts = append(parts, sql)
+		args = append(args, a...)
+	}
+	for i := range g.Groups {
+		sql, a, err := buildGroup(&g.Groups[i], columns, depth+1)
+		if err != nil {
+			return "", nil, err
+		}
+		if sql == "" {
+			continue
+		}
+		parts = append(parts, "("+sql+")")
+		args = append(args, a...)
+	}
+	return strings.Join(parts, joiner), args, nil
+}
+
+func buildCondition(c domain.FilterCondition, columns map[string]bool) (string, []interface{}, error) {
+	if !columns[c.Column] {
+		return "", nil, fmt.Errorf("unknown column %q: %w", c.Column, domain.ErrInvalidInput)
+	}
+	col := quoteIdent(c.Column)
+
+	if c.Value == nil && (c.Operator == domain.OpEq || c.Operator == "") {
+		return col + " IS NULL", nil, nil
+	}
+	if c.Value == nil && c.Operator == domain.OpNotEq {
+		return col + " IS NOT NULL", nil, nil
+	}
+
+	switch c.Operator {
+	case domain.OpEq, "", domain.OpNotEq, domain.OpLt, domain.OpLte, domain.OpGt, domain.OpGte,
+		domain.OpLike, domain.OpNotLike, domain.OpContains, domain.OpStartsWith, domain.OpEndsWith:
+		switch c.Value.(type) {
+		case []interface{}, map[string]interface{}:
+			return "", nil, fmt.Errorf("%s on %s needs a single value: %w", c.Operator, c.Column, domain.ErrInvalidInput)
+		}
+	}
+
+	switch c.Operator {
+	case domain.OpEq, "":
+		return col + " = ?", []interface{}{sqlArg(c.Value)}, nil
+	case domain.OpNotEq:
+		return col + " <> ?", []interface{}{sqlArg(c.Value)}, nil
+	case domain.OpLt:
+		return col + " < ?", []interface{}{sqlArg(c.Value)}, nil
+	case domain.OpLte:
+		return col + " <= ?", []interface{}{sqlArg(c.Value)}, nil
+	case domain.OpGt:
+		return col + " > ?", []interface{}{sqlArg(c.Value)}, nil
+	case domain.OpGte:
+		return col + " >= ?", []interface{}{sqlArg(c.Value)}, nil
+	case domain.OpLike:
+		return col + " LIKE ?", []interface{}{sqlArg(c.Value)}, nil
+	case domain.OpNotLike:
+		return col + " NOT LIKE ?", []interface{}{sqlArg(c.Value)}, nil
+	case domain.OpContains:
+		return col + " LIKE ?", []interface{}{"%" + escapeLike(c.Value) + "%"}, nil
+	case domain.OpStartsWith:
+		return col + " LIKE ?", []interface{}{escapeLike(c.Value) + "%"}, nil
+	case domain.OpEndsWith:
+		return col + " LIKE ?", []interface{}{"%" + escapeLike(c.Value)}, nil
+	case domain.OpIsNull:
+		return col + " IS NULL", nil, nil
+	case domain.OpNotNull:
+		return col + " IS NOT NULL", nil, nil
+	case domain.OpIn, domain.OpNotIn:
+		values, ok := c.Value.([]interface{})
+		if !ok || len(values) == 0 {
+			return "", nil, fmt.Errorf("%s on %s needs a non-empty array: %w", c.Operator, c.Column, domain.ErrInvalidInput)
+		}
+		phs := strings.TrimSuffix(strings.Repeat("?,", len(values)), ",")
+		keyword := " IN "
+		if c.Operator == domain.OpNotIn {
+			keyword = " NOT IN "
+		}
+		return col + keyword + "(" + phs + ")", sqlArgs(values), nil
+	case domain.OpBetween:
+		values, ok := c.Value.([]interface{})
+		if !ok || len(values) != 2 {
+			return "", nil, fmt.Errorf("between on %s needs [from, to]: %w", c.Column, domain.ErrInvalidInput)
+		}
+		return col + " BETWEEN ? AND ?", sqlArgs(values), nil
+	}
+	return "", nil, fmt.Errorf("unknown filter operator %q: %w", c.Operator, domain.ErrInvalidInput)
+}
+
+// escapeLike makes a user value match literally inside a LIKE pattern
+func escapeLike(v interface{}) string {
+	s := fmt.Sprint(v)
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// buildOrderBy returns the ORDER BY list (without the keywords)
+func buildOrderBy(sort []domain.SortField, columns map[string]bool) (string, error) {
+	parts := make([]string, 0, len(sort))
+	for _, s := range sort {
+		if !columns[s.Column] {
+			return "", fmt.Errorf("unknown sort column %q: %w", s.Column, domain.ErrInvalidInput)
+		}
+		dir := " ASC"
+		if s.Desc {
+			dir = " DESC"
+		}
+		parts = append(parts, quoteIdent(s.Column)+dir)
+	}
+	return strings.Join(parts, ", "), nil
+}
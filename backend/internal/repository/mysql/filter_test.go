@@ -0,0 +1,166 @@
+package mysql
+
+import (
+	"backend/internal/domain"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBuildCondition(t *testing.T) {
+	columns := map[string]bool{"id": true, "name": true, "a`b": true}
+	tests := []struct {
+		name string
+		cond domain.FilterCondition
+		sql  string
+		args []interface{}
+	}{
+		{"eq", domain.FilterCondition{Column: "id", Operator: domain.OpEq, Value: "1"}, "`id` = ?", []interface{}{"1"}},
+		{"default operator", domain.FilterCondition{Column: "id", Value: "1"}, "`id` = ?", []interface{}{"1"}},
+		{"eq null", domain.FilterCondition{Column: "id", Operator: domain.OpEq}, "`id` IS NULL", nil},
+		{"ne null", domain.FilterCondition{Column: "id", Operator: domain.OpNotEq}, "`id` IS NOT NULL", nil},
+		{"ne", domain.FilterCondition{Column: "id", Operator: domain.OpNotEq, Value: "1"}, "`id` <> ?", []interface{}{"1"}},
+		{"lt", domain.FilterCondition{Column: "id", Operator: domain.OpLt, Value: "1"}, "`id` < ?", []interface{}{"1"}},
+		{"lte", domain.FilterCondition{Column: "id", Operator: domain.OpLte, Value: "1"}, "`id` <= ?", []interface{}{"1"}},
+		{"gt", domain.FilterCondition{Column: "id", Operator: domain.OpGt, Value: "1"}, "`id` > ?", []interface{}{"1"}},
+		{"gte", domain.FilterCondition{Column: "id", Operator: domain.OpGte, Value: "1"}, "`id` >= ?", []interface{}{"1"}},
+		{"like", domain.FilterCondition{Column: "name", Operator: domain.OpLike, Value: "a%"}, "`name` LIKE ?", []interface{}{"a%"}},
+		{"not like", domain.FilterCondition{Column: "name", Operator: domain.OpNotLike, Value: "a%"}, "`name` NOT LIKE ?", []interface{}{"a%"}},
+		{"contains escapes", domain.FilterCondition{Column: "name", Operator: domain.OpContains, Value: `50%_\`}, "`name` LIKE ?", []interface{}{`%50\%\_\\%`}},
+		{"starts with", domain.FilterCondition{Column: "name", Operator: domain.OpStartsWith, Value: "a"}, "`name` LIKE ?", []interface{}{"a%"}},
+		{"ends with", domain.FilterCondition{Column: "name", Operator: domain.OpEndsWith, Value: "a"}, "`name` LIKE ?", []interface{}{"%a"}},
+		{"is null", domain.FilterCondition{Column: "name", Operator: domain.OpIsNull}, "`name` IS NULL", nil},
+		{"not null", domain.FilterCondition{Column: "name", Operator: domain.OpNotNull}, "`name` IS NOT NULL", nil},
+		{"in", domain.FilterCondition{Column: "id", Operator: domain.OpIn, Value: []interface{}{"1", "2"}}, "`id` IN (?,?)", []interface{}{"1", "2"}},
+		{"not in", domain.FilterCondition{Column: "id", Operator: domain.OpNotIn, Value: []interface{}{"1"}}, "`id` NOT IN (?)", []interface{}{"1"}},
+		{"between", domain.FilterCondition{Column: "id", Operator: domain.OpBetween, Value: []interface{}{"1", "9"}}, "`id` BETWEEN ? AND ?", []interface{}{"1", "9"}},
+		{"quoted column", domain.FilterCondition{Column: "a`b", Value: "x"}, "`a``b` = ?", []interface{}{"x"}},
+		{"big integer stays exact", domain.FilterCondition{Column: "id", Value: json.Number("9007199254740993")}, "`id` = ?", []interface{}{int64(9007199254740993)}},
+		{"decimal as text", domain.FilterCondition{Column: "id", Value: json.Number("1.50")}, "`id` = ?", []interface{}{"1.50"}},
+		{"in with numbers", domain.FilterCondition{Column: "id", Operator: domain.OpIn, Value: []interface{}{json.Number("1"), json.Number("18446744073709551615")}},
+			"`id` IN (?,?)", []interface{}{int64(1), "18446744073709551615"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := buildCondition(tt.cond, columns)
+			if err != nil {
+				t.Fatalf("buildCondition: %v", err)
+			}
+			if sql != tt.sql {
+				t.Errorf("sql = %q, want %q", sql, tt.sql)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestBuildConditionErrors(t *testing.T) {
+	columns := map[string]bool{"id": true}
+	tests := []struct {
+		name string
+		cond domain.FilterCondition
+	}{
+		{"unknown column", domain.FilterCondition{Column: "nope", Value: "1"}},
+		{"unknown operator", domain.FilterCondition{Column: "id", Operator: "regexp", Value: "1"}},
+		{"array for eq", domain.FilterCondition{Column: "id", Operator: domain.OpEq, Value: []interface{}{"1"}}},
+		{"object for like", domain.FilterCondition{Column: "id", Operator: domain.OpLike, Value: map[string]interface{}{}}},
+		{"empty in", domain.FilterCondition{Column: "id", Operator: domain.OpIn, Value: []interface{}{}}},
+		{"scalar in", domain.FilterCondition{Column: "id", Operator: domain.OpIn, Value: "1"}},
+		{"between one value", domain.FilterCondition{Column: "id", Operator: domain.OpBetween, Value: []interface{}{"1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := buildCondition(tt.cond, columns); !errors.Is(err, domain.ErrInvalidInput) {
+				t.Errorf("error = %v, want ErrInvalidInput", err)
+			}
+		})
+	}
+}
+
+func TestBuildWhere(t *testing.T) {
+	columns := map[string]bool{"a": true, "b": true, "c": true}
+	tests := []struct {
+		name  string
+		group *domain.FilterGroup
+		sql   string
+		args  []interface{}
+	}{
+		{"nil", nil, "", nil},
+		{"empty", &domain.FilterGroup{}, "", nil},
+		{
+			name: "and",
+			group: &domain.FilterGroup{Conditions: []domain.FilterCondition{
+				{Column: "a", Value: "1"}, {Column: "b", Operator: domain.OpGt, Value: "2"},
+			}},
+			sql:  "`a` = ? AND `b` > ?",
+			args: []interface{}{"1", "2"},
+		},
+		{
+			name: "nested or",
+			group: &domain.FilterGroup{
+				Conditions: []domain.FilterCondition{{Column: "a", Value: "1"}},
+				Groups: []domain.FilterGroup{{Logic: "OR", Conditions: []domain.FilterCondition{
+					{Column: "b", Value: "2"}, {Column: "c", Operator: domain.OpIsNull},
+				}}},
+			},
+			sql:  "`a` = ? AND (`b` = ? OR `c` IS NULL)",
+			args: []interface{}{"1", "2"},
+		},
+		{
+			name: "empty nested group is skipped",
+			group: &domain.FilterGroup{
+				Logic:      "or",
+				Conditions: []domain.FilterCondition{{Column: "a", Value: "1"}},
+				Groups:     []domain.FilterGroup{{}},
+			},
+			sql:  "`a` = ?",
+			args: []interface{}{"1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := buildWhere(tt.group, columns)
+			if err != nil {
+				t.Fatalf("buildWhere: %v", err)
+			}
+			if sql != tt.sql {
+				t.Errorf("sql = %q, want %q", sql, tt.sql)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestBuildWhereErrors(t *testing.T) {
+	deep := &domain.FilterGroup{Conditions: []domain.FilterCondition{{Column: "a", Value: "1"}}}
+	for i := 0; i <= maxFilterDepth; i++ {
+		deep = &domain.FilterGroup{Groups: []domain.FilterGroup{*deep}}
+	}
+	for name, g := range map[string]*domain.FilterGroup{
+		"unknown logic":     {Logic: "xor", Conditions: []domain.FilterCondition{{Column: "a", Value: "1"}}},
+		"nested bad column": {Groups: []domain.FilterGroup{{Conditions: []domain.FilterCondition{{Column: "z", Value: "1"}}}}},
+		"nested too deeply": deep,
+	} {
+		if _, _, err := buildWhere(g, map[string]bool{"a": true}); !errors.Is(err, domain.ErrInvalidInput) {
+			t.Errorf("%s: error = %v, want ErrInvalidInput", name, err)
+		}
+	}
+}
+
+func TestBuildOrderBy(t *testing.T) {
+	got, err := buildOrderBy([]domain.SortField{{Column: "a"}, {Column: "b", Desc: true}}, map[string]bool{"a": true, "b": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "`a` ASC, `b` DESC"; got != want {
+		t.Errorf("buildOrderBy = %q, want %q", got, want)
+	}
+	if _, err := buildOrderBy([]domain.SortField{{Column: "z"}}, map[string]bool{"a": true}); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("unknown column error = %v, want ErrInvalidInput", err)
+	}
+}
@@ -3,6 +3,7 @@ package mysql
 import (
 	"backend/internal/domain"
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"strings"
@@ -12,35 +13,64 @@ import (
 )
 
 type mysqlRepository struct {
-	db *gorm.DB
-	mu sync.RWMutex
+	db       *gorm.DB
+	readOnly bool // the connection is configured read_only
+	mu       sync.RWMutex
 }
 
-func NewMySQLRepository(db *gorm.DB) domain.SchemaRepository {
-	return &mysqlRepository{db: db}
+func NewMySQLRepository(db *gorm.DB, readOnly bool) domain.SchemaRepository {
+	return &mysqlRepository{db: db, readOnly: readOnly}
 }
 
-func (r *mysqlRepository) SetDB(db *gorm.DB) {
+// SetDB switches to db; the read-only flag changes with it, so no request
+// sees the new connection with the old flag
+func (r *mysqlRepository) SetDB(db *gorm.DB, readOnly bool) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.db = db
+	r.readOnly = readOnly
 }
 
 func (r *mysqlRepository) getDB() (*gorm.DB, error) {
+	db, _, err := r.current()
+	return db, err
+}
+
+// current returns the connection and its read-only flag, read together
+func (r *mysqlRepository) current() (*gorm.DB, bool, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
     
     // Cek apakah pointer nil ATAU DB-nya punya error internal (broken connection)
 	if r.db == nil {
-		return nil, fmt.Errorf("database connection not established. please configure connection in settings")
+		return nil, false, fmt.Errorf("database connection not established. please configure connection in settings")
 	}
     
     // GORM kadang mengembalikan objek DB yang punya Error di dalamnya jika gagal konek
     if r.db.Error != nil {
-        return nil, fmt.Errorf("database connection is broken: %v. please re-connect", r.db.Error)
+        return nil, false, fmt.Errorf("database connection is broken: %v. please re-connect", r.db.Error)
     }
 
-	return r.db, nil
+	return r.db, r.readOnly, nil
+}
+
+func (r *mysqlRepository) ReadOnly() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.readOnly
+}
+
+// writableDB is getDB for operations that change the database; they are
+// refused on read-only connections
+func (r *mysqlRepository) writableDB() (*gorm.DB, error) {
+	db, readOnly, err := r.current()
+	if err != nil {
+		return nil, err
+	}
+	if readOnly {
+		return nil, domain.ErrReadOnly
+	}
+	return db, nil
 }
 
 // Database Operations
@@ -57,7 +87,7 @@ func (r *mysqlRepository) GetDatabases(ctx context.Context) ([]string, error) {
 }
 
 func (r *mysqlRepository) CreateDatabase(ctx context.Context, name string) error {
-	db, err := r.getDB()
+	db, err := r.writableDB()
 	if err != nil {
 		return err
 	}
@@ -65,7 +95,7 @@ func (r *mysqlRepository) CreateDatabase(ctx context.Context, name string) error
 }
 
 func (r *mysqlRepository) DropDatabase(ctx context.Context, name string) error {
-	db, err := r.getDB()
+	db, err := r.writableDB()
 	if err != nil {
 		return err
 	}
@@ -146,7 +176,7 @@ func (r *mysqlRepository) GetFullSchema(ctx context.Context, dbName string) (*do
 }
 
 func (r *mysqlRepository) SyncBatch(ctx context.Context, dbName string, reqs []domain.TableRequest) error {
-	db, err := r.getDB()
+	db, err := r.writableDB()
 	if err != nil {
 		return err
 	}
@@ -242,7 +272,7 @@ func (r *mysqlRepository) SyncBatch(ctx context.Context, dbName string, reqs []d
 }
 
 func (r *mysqlRepository) DropTable(ctx context.Context, name string) error {
-	db, err := r.getDB()
+	db, err := r.writableDB()
 	if err != nil {
 		return err
 	}
@@ -250,68 +280,220 @@ func (r *mysqlRepository) DropTable(ctx context.Context, name string) error {
 }
 
 // Data Operations
-func (r *mysqlRepository) GetTableData(ctx context.Context, tableName string, limit, offset int) (*domain.TableData, error) {
+
+// columnInfo is one row of SHOW COLUMNS
+type columnInfo struct {
+	Field   string
+	Type    string
+	Null    string
+	Key     string
+	Default *string
+	Extra   string
+}
+
+func (r *mysqlRepository) tableColumns(tx *gorm.DB, tableName string) ([]columnInfo, error) {
+	var cols []columnInfo
+	if err := tx.Raw("SHOW COLUMNS FROM " + quoteIdent(tableName)).Scan(&cols).Error; err != nil {
+		return nil, err
+	}
+	return cols, nil
+}
+
+func columnMetas(cols []columnInfo) []domain.ColumnMeta {
+	metas := make([]domain.ColumnMeta, len(cols))
+	for i, c := range cols {
+		metas[i] = domain.ColumnMeta{
+			Name:            c.Field,
+			Type:            c.Type,
+			Nullable:        c.Null == "YES",
+			IsPK:            c.Key == "PRI",
+			IsAutoIncrement: strings.Contains(c.Extra, "auto_increment"),
+			IsGenerated:     isGeneratedColumn(c.Extra),
+			Default:         c.Default,
+		}
+	}
+	return metas
+}
+
+// isGeneratedColumn matches "VIRTUAL GENERATED" and "STORED GENERATED" but
+// not "DEFAULT_GENERATED", which MySQL 8 shows for expression defaults
+func isGeneratedColumn(extra string) bool {
+	extra = strings.ToUpper(extra)
+	return strings.Contains(extra, "VIRTUAL GENERATED") || strings.Contains(extra, "STORED GENERATED")
+}
+
+func columnSet(cols []columnInfo) map[string]bool {
+	set := make(map[string]bool, len(cols))
+	for _, c := range cols { set[c.Field] = true }
+	return set
+}
+
+func (r *mysqlRepository) GetTableData(ctx context.Context, tableName string, q domain.DataQuery) (*domain.TableData, error) {
 	db, err := r.getDB()
 	if err != nil {
 		return nil, err
 	}
 	tx := db.WithContext(ctx)
-	var dbColumns []struct { Field string }
-	if err := tx.Raw(fmt.Sprintf("SHOW COLUMNS FROM `%s`", tableName)).Scan(&dbColumns).Error; err != nil {
+	dbColumns, err := r.tableColumns(tx, tableName)
+	if err != nil {
 		return nil, err
 	}
 	colNames := make([]string, len(dbColumns))
 	for i, c := range dbColumns { colNames[i] = c.Field }
+	cols := columnSet(dbColumns)
+
+	where, args, err := buildWhere(q.Filter, cols)
+	if err != nil { return nil, err }
+
+	sort := q.Sort
+	var cursorWhere string
+	var cursorArgs []interface{}
+	if q.Keyset {
+		if sort, err = keysetColumns(q.Sort, dbColumns); err != nil { return nil, err }
+		if q.Cursor != "" {
+			values, err := decodeCursor(q.Cursor, sort)
+			if err != nil { return nil, err }
+			cursorWhere, cursorArgs = keysetAfter(sort, values)
+		}
+	}
+	orderBy, err := buildOrderBy(sort, cols)
+	if err != nil { return nil, err }
 
-	var total int64
-	tx.Table(tableName).Count(&total)
+	data := &domain.TableData{ Columns: colNames, ColumnMeta: columnMetas(dbColumns) }
+	if data.Total, data.TotalEstimated, err = r.countRows(tx, tableName, where, args, q.Count); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT * FROM " + quoteIdent(tableName)
+	conds := []string{}
+	if where != "" { conds = append(conds, "("+where+")") }
+	if cursorWhere != "" { conds = append(conds, cursorWhere) }
+	if len(conds) > 0 { query += " WHERE " + strings.Join(conds, " AND ") }
+	if orderBy != "" { query += " ORDER BY " + orderBy }
+	queryArgs := append(append([]interface{}{}, args...), cursorArgs...)
+
+	if q.Keyset {
+		// read one extra row to know whether there is a next page
+		query += " LIMIT ?"
+		queryArgs = append(queryArgs, q.Limit+1)
+	} else {
+		query += " LIMIT ? OFFSET ?"
+		queryArgs = append(queryArgs, q.Limit, q.Offset)
+	}
 
-	var rows []map[string]interface{}
-	query := fmt.Sprintf("SELECT * FROM `%s` LIMIT ? OFFSET ?", tableName)
-	if err := tx.Raw(query, limit, offset).Scan(&rows).Error; err != nil { return nil, err }
+	rows, err := scanRows(tx.Raw(query, queryArgs...), dbColumns)
+	if err != nil { return nil, err }
 
-	return &domain.TableData{ Columns: colNames, Rows: rows, Total: total }, nil
+	if q.Keyset && len(rows) > q.Limit {
+		rows = rows[:q.Limit]
+		if data.NextCursor, err = encodeCursor(sort, rows[len(rows)-1]); err != nil { return nil, err }
+	}
+	data.Rows = rows
+	return data, nil
+}
+
+// countRows returns the number of rows matching where, either exactly or as
+// an optimizer estimate (TABLE_ROWS, or EXPLAIN when filtered).
+func (r *mysqlRepository) countRows(tx *gorm.DB, tableName, where string, args []interface{}, mode string) (int64, bool, error) {
+	from := " FROM " + quoteIdent(tableName)
+	if where != "" { from += " WHERE " + where }
+
+	switch mode {
+	case domain.CountNone:
+		return -1, false, nil
+	case domain.CountEstimate:
+		var n int64
+		if where == "" {
+			err := tx.Raw("SELECT COALESCE(TABLE_ROWS, 0) FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?", tableName).Scan(&n).Error
+			return n, true, err
+		}
+		var plan []struct{ Rows *int64 }
+		if err := tx.Raw("EXPLAIN SELECT *"+from, args...).Scan(&plan).Error; err != nil {
+			return 0, false, err
+		}
+		if len(plan) > 0 && plan[0].Rows != nil {
+			n = *plan[0].Rows
+		}
+		return n, true, nil
+	}
+
+	var total int64
+	err := tx.Raw("SELECT COUNT(*)"+from, args...).Scan(&total).Error
+	return total, false, err
 }
 
 func (r *mysqlRepository) InsertData(ctx context.Context, tableName string, data map[string]interface{}) error {
-	db, err := r.getDB()
+	db, err := r.writableDB()
 	if err != nil {
 		return err
 	}
-	cols := []string{}; vals := []interface{}{}; phs := []string{}
-	for k, v := range data {
-		cols = append(cols, fmt.Sprintf("`%s`", k))
-		vals = append(vals, v)
-		phs = append(phs, "?")
-	}
-	query := fmt.Sprintf("INSERT INTO `%s` (%s) VALUES (%s)", tableName, strings.Join(cols, ","), strings.Join(phs, ","))
-	return db.WithContext(ctx).Exec(query, vals...).Error
+	_, err = insertRow(db.WithContext(ctx), tableName, data)
+	return err
 }
 
-func (r *mysqlRepository) DeleteData(ctx context.Context, tableName string, condition map[string]interface{}) error {
+// GetColumns describes the columns of a table (type, nullability, key)
+func (r *mysqlRepository) GetColumns(ctx context.Context, tableName string) ([]domain.ColumnMeta, error) {
 	db, err := r.getDB()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	cols, err := r.tableColumns(db.WithContext(ctx), tableName)
+	if err != nil {
+		return nil, err
+	}
+	return columnMetas(cols), nil
+}
+
+func (r *mysqlRepository) UpdateData(ctx context.Context, tableName string, condition, values map[string]interface{}) (int64, error) {
+	db, err := r.writableDB()
+	if err != nil {
+		return 0, err
+	}
+	tx := db.WithContext(ctx)
+	cols, err := r.tableColumns(tx, tableName)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkColumns(condition, columnSet(cols)); err != nil { return 0, err }
+	if err := checkColumns(values, columnSet(cols)); err != nil { return 0, err }
+	return updateRow(tx, tableName, condition, values)
+}
+
+func (r *mysqlRepository) DeleteData(ctx context.Context, tableName string, condition map[string]interface{}) (int64, error) {
+	db, err := r.writableDB()
+	if err != nil {
+		return 0, err
 	}
-	tx := db.WithContext(ctx).Table(tableName)
-	for k, v := range condition { tx = tx.Where(fmt.Sprintf("`%s` = ?", k), v) }
-	return tx.Delete(nil).Error
+	tx := db.WithContext(ctx)
+	cols, err := r.tableColumns(tx, tableName)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkColumns(condition, columnSet(cols)); err != nil { return 0, err }
+	return deleteRow(tx, tableName, condition)
 }
 
 func (r *mysqlRepository) ExecuteRaw(ctx context.Context, query string) ([]map[string]interface{}, error) {
-    db, err := r.getDB()
+    db, readOnly, err := r.current()
 	if err != nil {
         return nil, err
     }
     var results []map[string]interface{}
-    if err := db.WithContext(ctx).Raw(query).Scan(&results).Error; err != nil {
+    if readOnly {
+        err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+            return tx.Raw(query).Scan(&results).Error
+        }, &sql.TxOptions{ReadOnly: true})
+    } else {
+        err = db.WithContext(ctx).Raw(query).Scan(&results).Error
+    }
+    if err != nil {
         return nil, err
     }
     return results, nil
 }
 
 func (r *mysqlRepository) ExecuteDDL(ctx context.Context, query string) error {
-    db, err := r.getDB()
+    db, err := r.writableDB()
 	if err != nil {
         return err
     }
@@ -319,27 +501,30 @@ func (r *mysqlRepository) ExecuteDDL(ctx context.Context, query string) error {
     return db.WithContext(ctx).Exec(query).Error
 }
 
-func (r *mysqlRepository) SaveLayout(ctx context.Context, layouts map[string]interface{}) error {
+// GetLegacyLayout reads the _layout table older versions created inside the
+// user's database. It returns nil when there is nothing to migrate.
+func (r *mysqlRepository) GetLegacyLayout(ctx context.Context, dbName string) (*domain.Layout, error) {
     db, err := r.getDB()
 	if err != nil {
-        return err
-    }
-    db.Exec(`CREATE TABLE IF NOT EXISTS _layout (table_name VARCHAR(255) PRIMARY KEY, x INT, y INT)`)
-    for name, pos := range layouts {
-        p := pos.(map[string]interface{})
-        db.Exec("REPLACE INTO _layout (table_name, x, y) VALUES (?, ?, ?)", name, p["x"], p["y"])
+        return nil, err
     }
-    return nil
-}
+    tx := db.WithContext(ctx)
 
-func (r *mysqlRepository) GetLayout(ctx context.Context) (map[string]interface{}, error) {
-    db, err := r.getDB()
-	if err != nil {
+    var count int64
+    if err := tx.Raw("SELECT count(*) FROM information_schema.tables WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE()) AND table_name = '_layout'", dbName).Scan(&count).Error; err != nil {
         return nil, err
     }
+    if count == 0 {
+        return nil, nil
+    }
+
+    table := "`_layout`"
+    if dbName != "" {
+        table = quoteIdent(dbName) + ".`_layout`"
+    }
     var rows []struct { TableName string; X int; Y int }
-    if err := db.Raw("SELECT * FROM _layout").Scan(&rows).Error; err != nil { return nil, err }
-    res := make(map[string]interface{})
-    for _, r := range rows { res[r.TableName] = map[string]int{"x": r.X, "y": r.Y} }
+    if err := tx.Raw("SELECT table_name, x, y FROM " + table).Scan(&rows).Error; err != nil { return nil, err }
+    res := domain.NewLayout()
+    for _, r := range rows { res.Nodes[r.TableName] = domain.NodeLayout{X: float64(r.X), Y: float64(r.Y)} }
     return res, nil
 }
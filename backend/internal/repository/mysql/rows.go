@@ -0,0 +1,256 @@
+package mysql
+
+import (
+	"backend/internal/domain"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Row level writes shared by the single-row endpoints and changesets.
+// Column names are validated against the table before they are quoted
+// into the statement; values are always bound as arguments.
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func checkColumns(m map[string]interface{}, columns map[string]bool) error {
+	for k := range m {
+		if !columns[k] {
+			return fmt.Errorf("unknown column %q: %w", k, domain.ErrInvalidInput)
+		}
+	}
+	return nil
+}
+
+// matchClause builds a null-safe equality match for every column in cond
+func matchClause(cond map[string]interface{}) (string, []interface{}) {
+	parts := make([]string, 0, len(cond))
+	args := make([]interface{}, 0, len(cond))
+	for _, k := range sortedKeys(cond) {
+		parts = append(parts, quoteIdent(k)+" <=> ?")
+		args = append(args, sqlArg(cond[k]))
+	}
+	return strings.Join(parts, " AND "), args
+}
+
+// insertRow inserts one row and returns the AUTO_INCREMENT id, if any
+func insertRow(tx *gorm.DB, tableName string, data map[string]interface{}) (int64, error) {
+	cols := []string{}
+	vals := []interface{}{}
+	phs := []string{}
+	for _, k := range sortedKeys(data) {
+		cols = append(cols, quoteIdent(k))
+		vals = append(vals, sqlArg(data[k]))
+		phs = append(phs, "?")
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdent(tableName), strings.Join(cols, ","), strings.Join(phs, ","))
+	// Go through the connection pool directly to get the sql.Result, so the
+	// insert id comes from the same connection (or transaction) as the insert.
+	res, err := tx.Statement.ConnPool.ExecContext(tx.Statement.Context, query, vals...)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// updateRow changes at most one row matching cond
+func updateRow(tx *gorm.DB, tableName string, cond, values map[string]interface{}) (int64, error) {
+	if len(cond) == 0 {
+		return 0, fmt.Errorf("refusing to update without a row key: %w", domain.ErrInvalidInput)
+	}
+	if len(values) == 0 {
+		return 0, fmt.Errorf("no values to update: %w", domain.ErrInvalidInput)
+	}
+	sets := make([]string, 0, len(values))
+	args := make([]interface{}, 0, len(values)+len(cond))
+	for _, k := range sortedKeys(values) {
+		sets = append(sets, quoteIdent(k)+" = ?")
+		args = append(args, sqlArg(values[k]))
+	}
+	where, whereArgs := matchClause(cond)
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s LIMIT 1", quoteIdent(tableName), strings.Join(sets, ", "), where)
+	res := tx.Exec(query, append(args, whereArgs...)...)
+	return res.RowsAffected, res.Error
+}
+
+// deleteRow removes at most one row matching cond
+func deleteRow(tx *gorm.DB, tableName string, cond map[string]interface{}) (int64, error) {
+	if len(cond) == 0 {
+		return 0, fmt.Errorf("refusing to delete without a row key: %w", domain.ErrInvalidInput)
+	}
+	where, args := matchClause(cond)
+	res := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s LIMIT 1", quoteIdent(tableName), where), args...)
+	return res.RowsAffected, res.Error
+}
+
+// InsertBatches runs fill inside one transaction. Each call to insert
+// writes its rows with a single multi-row INSERT.
+func (r *mysqlRepository) InsertBatches(ctx context.Context, tableName string, fill func(insert func(columns []string, rows [][]interface{}) error) error) (int64, error) {
+	db, err := r.writableDB()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		dbColumns, err := r.tableColumns(tx, tableName)
+		if err != nil {
+			return err
+		}
+		known := columnSet(dbColumns)
+		return fill(func(columns []string, rows [][]interface{}) error {
+			n, err := insertRows(tx, tableName, columns, known, rows)
+			total += n
+			return err
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func insertRows(tx *gorm.DB, tableName string, columns []string, known map[string]bool, rows [][]interface{}) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		if !known[c] {
+			return 0, fmt.Errorf("unknown column %q: %w", c, domain.ErrInvalidInput)
+		}
+		quoted[i] = quoteIdent(c)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "INSERT INTO %s (%s) VALUES ", quoteIdent(tableName), strings.Join(quoted, ","))
+	args := make([]interface{}, 0, len(rows)*len(columns))
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return 0, fmt.Errorf("row has %d values for %d columns: %w", len(row), len(columns), domain.ErrInvalidInput)
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('(')
+		for j, v := range row {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			if _, ok := v.(domain.DefaultValue); ok {
+				b.WriteString("DEFAULT")
+				continue
+			}
+			b.WriteByte('?')
+			args = append(args, v)
+		}
+		b.WriteByte(')')
+	}
+	res, err := tx.Statement.ConnPool.ExecContext(tx.Statement.Context, b.String(), args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// isBinaryType reports whether a column holds bytes rather than text
+// (BIT and spatial values also arrive as raw bytes)
+func isBinaryType(sqlType string) bool {
+	base := strings.ToLower(sqlType)
+	if i := strings.IndexAny(base, "( "); i >= 0 {
+		base = base[:i]
+	}
+	switch base {
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "bit",
+		"geometry", "point", "linestring", "polygon", "multipoint", "multilinestring", "multipolygon", "geometrycollection":
+		return true
+	}
+	return false
+}
+
+// scanRows reads a result set into maps of driver values. Text columns
+// come back from the driver as bytes; they are turned into strings here
+// so that only binary columns stay []byte.
+func scanRows(query *gorm.DB, cols []columnInfo) ([]map[string]interface{}, error) {
+	binary := make(map[string]bool, len(cols))
+	for _, c := range cols {
+		binary[c.Field] = isBinaryType(c.Type)
+	}
+	rows, err := query.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	out := []map[string]interface{}{}
+	for rows.Next() {
+		values := make([]interface{}, len(names))
+		ptrs := make([]interface{}, len(names))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(names))
+		for i, name := range names {
+			if b, ok := values[i].([]byte); ok && !binary[name] {
+				row[name] = string(b)
+			} else {
+				row[name] = values[i]
+			}
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+// GetValue reads a single column of the row identified by key
+func (r *mysqlRepository) GetValue(ctx context.Context, tableName, column string, key map[string]interface{}) (interface{}, error) {
+	db, err := r.getDB()
+	if err != nil {
+		return nil, err
+	}
+	tx := db.WithContext(ctx)
+	cols, err := r.tableColumns(tx, tableName)
+	if err != nil {
+		return nil, err
+	}
+	known := columnSet(cols)
+	if !known[column] {
+		return nil, fmt.Errorf("unknown column %q: %w", column, domain.ErrInvalidInput)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("a row key is required: %w", domain.ErrInvalidInput)
+	}
+	if err := checkColumns(key, known); err != nil {
+		return nil, err
+	}
+	where, args := matchClause(key)
+	var target []columnInfo
+	for _, c := range cols {
+		if c.Field == column {
+			target = append(target, c)
+		}
+	}
+	rows, err := scanRows(tx.Raw("SELECT "+quoteIdent(column)+" FROM "+quoteIdent(tableName)+" WHERE "+where+" LIMIT 1", args...), target)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("row not found in %s: %w", tableName, domain.ErrNotFound)
+	}
+	return rows[0][column], nil
+}
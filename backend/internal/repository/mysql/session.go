@@ -0,0 +1,162 @@
+package mysql
+
+import (
+	"backend/internal/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// killTimeout bounds the KILL QUERY sent when a statement is cancelled
+const killTimeout = 5 * time.Second
+
+// sqlSession is a console session on a connection taken out of the pool.
+// On a read-only connection statements run in a read-only transaction
+// that is rolled back on Close.
+type sqlSession struct {
+	db   *sql.DB
+	conn *sql.Conn
+	tx   *sql.Tx
+	id   int64 // CONNECTION_ID() of conn
+}
+
+// sessionConn is what statements run on: the connection or its transaction
+type sessionConn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func (s *sqlSession) target() sessionConn {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.conn
+}
+
+func (r *mysqlRepository) OpenSession(ctx context.Context) (domain.SQLSession, error) {
+	db, readOnly, err := r.current()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s := &sqlSession{db: sqlDB, conn: conn}
+	if err := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&s.id); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if readOnly {
+		if s.tx, err = conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Run executes statement. When ctx ends first, the driver only drops the
+// connection and the server would keep executing, so the statement is
+// also killed from another connection.
+func (s *sqlSession) Run(ctx context.Context, statement string, returnsRows bool, maxRows int, args ...interface{}) (*domain.StatementResult, error) {
+	finished, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			s.kill()
+		case <-finished:
+		}
+	}()
+	res, err := s.run(ctx, statement, returnsRows, maxRows, args)
+	// wait for a pending kill so it cannot hit the next statement
+	close(finished)
+	<-stopped
+	return res, err
+}
+
+func (s *sqlSession) kill() {
+	ctx, cancel := context.WithTimeout(context.Background(), killTimeout)
+	defer cancel()
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("KILL QUERY %d", s.id)); err != nil {
+		log.Printf("Killing query on connection %d failed: %v", s.id, err)
+	}
+}
+
+func (s *sqlSession) run(ctx context.Context, statement string, returnsRows bool, maxRows int, args []interface{}) (*domain.StatementResult, error) {
+	res := &domain.StatementResult{Statement: statement}
+	if !returnsRows {
+		result, err := s.target().ExecContext(ctx, statement, args...)
+		if err != nil {
+			return nil, err
+		}
+		res.RowsAffected, _ = result.RowsAffected()
+		res.LastInsertID, _ = result.LastInsertId()
+		return res, nil
+	}
+
+	rows, err := s.target().QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	binary := make([]bool, len(types))
+	res.Columns = make([]domain.ResultColumn, len(types))
+	for i, t := range types {
+		nullable, _ := t.Nullable()
+		res.Columns[i] = domain.ResultColumn{Name: t.Name(), Type: t.DatabaseTypeName(), Nullable: nullable}
+		binary[i] = isBinaryType(t.DatabaseTypeName())
+	}
+
+	res.Rows = [][]interface{}{}
+	for rows.Next() {
+		if maxRows > 0 && len(res.Rows) == maxRows {
+			res.Truncated = true
+			break
+		}
+		values := make([]interface{}, len(types))
+		ptrs := make([]interface{}, len(types))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok && !binary[i] {
+				values[i] = string(b)
+			}
+		}
+		res.Rows = append(res.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	res.RowsAffected = int64(len(res.Rows))
+	return res, nil
+}
+
+func (s *sqlSession) Use(ctx context.Context, database string) error {
+	_, err := s.target().ExecContext(ctx, "USE "+quoteIdent(database))
+	return err
+}
+
+func (s *sqlSession) ConnectionID() int64 { return s.id }
+
+func (s *sqlSession) Close() error {
+	if s.tx != nil {
+		s.tx.Rollback()
+	}
+	return s.conn.Close()
+}
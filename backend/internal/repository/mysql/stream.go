@@ -0,0 +1,123 @@
+package mysql
+
+import (
+	"backend/internal/domain"
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+)
+
+// sqlRowStream adapts *sql.Rows to domain.RowStream
+type sqlRowStream struct {
+	rows    *sql.Rows
+	tx      *gorm.DB // read-only transaction owning rows, if any
+	columns []string
+	values  []interface{}
+	err     error
+}
+
+func newRowStream(rows *sql.Rows) (*sqlRowStream, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+	return &sqlRowStream{rows: rows, columns: cols}, nil
+}
+
+func (s *sqlRowStream) Columns() []string { return s.columns }
+
+func (s *sqlRowStream) Next() bool {
+	if s.err != nil || !s.rows.Next() {
+		return false
+	}
+	raw := make([]interface{}, len(s.columns))
+	ptrs := make([]interface{}, len(s.columns))
+	for i := range raw {
+		ptrs[i] = &raw[i]
+	}
+	if s.err = s.rows.Scan(ptrs...); s.err != nil {
+		return false
+	}
+	s.values = raw
+	return true
+}
+
+func (s *sqlRowStream) Values() []interface{} { return s.values }
+
+func (s *sqlRowStream) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.rows.Err()
+}
+
+func (s *sqlRowStream) Close() error {
+	err := s.rows.Close()
+	if s.tx != nil {
+		s.tx.Rollback()
+	}
+	return err
+}
+
+// StreamTable opens a cursor over a whole table (filter and sort applied,
+// no paging) for exports. The caller must Close the stream.
+func (r *mysqlRepository) StreamTable(ctx context.Context, tableName string, q domain.DataQuery) (domain.RowStream, error) {
+	db, err := r.getDB()
+	if err != nil {
+		return nil, err
+	}
+	tx := db.WithContext(ctx)
+	dbColumns, err := r.tableColumns(tx, tableName)
+	if err != nil {
+		return nil, err
+	}
+	cols := columnSet(dbColumns)
+	where, args, err := buildWhere(q.Filter, cols)
+	if err != nil {
+		return nil, err
+	}
+	orderBy, err := buildOrderBy(q.Sort, cols)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT * FROM " + quoteIdent(tableName)
+	if where != "" {
+		query += " WHERE " + where
+	}
+	if orderBy != "" {
+		query += " ORDER BY " + orderBy
+	}
+	rows, err := tx.Raw(query, args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	return newRowStream(rows)
+}
+
+// StreamQuery opens a cursor over the result of an ad-hoc query. It runs
+// inside a read-only transaction so the statement cannot modify data.
+func (r *mysqlRepository) StreamQuery(ctx context.Context, query string) (domain.RowStream, error) {
+	db, err := r.getDB()
+	if err != nil {
+		return nil, err
+	}
+	tx := db.WithContext(ctx).Begin(&sql.TxOptions{ReadOnly: true})
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	rows, err := tx.Raw(query).Rows()
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	stream, err := newRowStream(rows)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	stream.tx = tx
+	return stream, nil
+}
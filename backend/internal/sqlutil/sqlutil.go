@@ -0,0 +1,156 @@
+// Package sqlutil scans MySQL statement text: quoted strings, comments and
+// the keyword that says what a statement does. The console and the data
+// export both classify statements with it, so they agree on what is a read.
+package sqlutil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Verb returns the upper-case keyword that identifies a statement (SELECT,
+// UPDATE, SHOW, ...), skipping leading comments, whitespace and
+// parentheses. A WITH statement is identified by the statement that
+// follows its common table expressions; "" when there is none.
+func Verb(stmt string) string {
+	keyword := strings.ToUpper(FirstKeyword(stmt))
+	if keyword == "WITH" {
+		return verbAfterWith(stmt)
+	}
+	return keyword
+}
+
+// IsRead reports whether a statement only reads: a query, SHOW, DESCRIBE,
+// EXPLAIN and the like, but not SELECT ... INTO OUTFILE, which writes a
+// file on the server.
+func IsRead(stmt string) bool {
+	switch Verb(stmt) {
+	case "SELECT", "SHOW", "DESCRIBE", "DESC", "EXPLAIN", "TABLE", "VALUES", "HELP", "CHECK", "CHECKSUM":
+	default:
+		return false
+	}
+	upper := strings.ToUpper(stmt)
+	return !strings.Contains(upper, "INTO OUTFILE") && !strings.Contains(upper, "INTO DUMPFILE")
+}
+
+// verbAfterWith returns the statement keyword (SELECT, UPDATE, ...) that
+// follows the common table expressions of a WITH statement, or "" when it
+// cannot be found. CTE names cannot be such unquoted keywords, so the first
+// one outside parentheses, quotes and comments is the statement's.
+func verbAfterWith(stmt string) string {
+	s := strings.TrimLeft(SkipComments(stmt), "( \t\r\n")
+	depth := 0
+	for i := len("WITH"); i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end, err := SkipQuoted(s, i)
+			if err != nil {
+				return ""
+			}
+			i = end
+			continue
+		case c == '#' || strings.HasPrefix(s[i:], "--") && (i+2 == len(s) || IsSpace(s[i+2])):
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				return ""
+			}
+			i += end
+			continue
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return ""
+			}
+			i += end + 4
+			continue
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && IsIdentStart(c) && !IsIdentChar(s[i-1]):
+			j := i
+			for j < len(s) && IsIdentChar(s[j]) {
+				j++
+			}
+			switch word := strings.ToUpper(s[i:j]); word {
+			case "SELECT", "TABLE", "VALUES", "INSERT", "REPLACE", "UPDATE", "DELETE":
+				return word
+			}
+			i = j
+			continue
+		}
+		i++
+	}
+	return ""
+}
+
+// FirstKeyword returns the first word of a statement as written, after
+// leading comments, whitespace and parentheses
+func FirstKeyword(stmt string) string {
+	s := SkipComments(stmt)
+	s = strings.TrimLeft(s, "( \t\r\n")
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_')
+	})
+	if end < 0 {
+		return s
+	}
+	return s[:end]
+}
+
+// SkipComments drops whitespace and -- , # and /* */ comments at the
+// start of s
+func SkipComments(s string) string {
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		switch {
+		case strings.HasPrefix(s, "--"), strings.HasPrefix(s, "#"):
+			i := strings.IndexByte(s, '\n')
+			if i < 0 {
+				return ""
+			}
+			s = s[i+1:]
+		case strings.HasPrefix(s, "/*"):
+			i := strings.Index(s[2:], "*/")
+			if i < 0 {
+				return ""
+			}
+			s = s[i+4:]
+		default:
+			return s
+		}
+	}
+}
+
+// SkipQuoted returns the index just past the quoted string starting at i.
+// Quotes are escaped by doubling them, and by backslash except in
+// backtick identifiers.
+func SkipQuoted(s string, i int) (int, error) {
+	q := s[i]
+	for j := i + 1; j < len(s); j++ {
+		switch {
+		case s[j] == '\\' && q != '`':
+			j++
+		case s[j] == q:
+			if j+1 < len(s) && s[j+1] == q {
+				j++
+				continue
+			}
+			return j + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated %c quote", q)
+}
+
+func IsSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func IsIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func IsIdentChar(c byte) bool {
+	return IsIdentStart(c) || c >= '0' && c <= '9'
+}
@@ -0,0 +1,90 @@
+package sqlutil
+
+import "testing"
+
+func TestVerb(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want string
+	}{
+		{"select 1", "SELECT"},
+		{"  (SELECT 1) UNION (SELECT 2)", "SELECT"},
+		{"-- note\nDELETE FROM t", "DELETE"},
+		{"# note\nshow tables", "SHOW"},
+		{"/* a */ /* b */ UPDATE t SET a = 1", "UPDATE"},
+		{"/* open", ""},
+		{"", ""},
+		{"WITH c AS (SELECT 1) SELECT * FROM c", "SELECT"},
+		{"with recursive c (n) as (select 1 union all select n + 1 from c where n < 3) select * from c", "SELECT"},
+		{"WITH c AS (SELECT id FROM t) DELETE FROM t WHERE id IN (SELECT id FROM c)", "DELETE"},
+		{"WITH c AS (SELECT 1) UPDATE t, c SET t.a = 1", "UPDATE"},
+		{"/* x */ WITH c AS (SELECT 1) INSERT INTO t SELECT * FROM c", "INSERT"},
+		{"WITH c AS (SELECT ') delete' AS s) SELECT * FROM c", "SELECT"},
+		{"WITH `delete` AS (SELECT 1) SELECT * FROM `delete`", "SELECT"},
+		{"WITH c AS (SELECT 1 /* ) delete */) TABLE c", "TABLE"},
+		{"WITH c AS (SELECT 1 -- ) delete\n) SELECT * FROM c", "SELECT"},
+		{"WITH c AS (SELECT 1)", ""},
+		{"WITH c AS (SELECT 'open", ""},
+	}
+	for _, tt := range tests {
+		if got := Verb(tt.stmt); got != tt.want {
+			t.Errorf("Verb(%q) = %q, want %q", tt.stmt, got, tt.want)
+		}
+	}
+}
+
+func TestIsRead(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want bool
+	}{
+		{"SELECT * FROM t", true},
+		{"/* report */\n-- weekly\nSELECT 1", true},
+		{"(SELECT 1)", true},
+		{"WITH c AS (SELECT 1) SELECT * FROM c", true},
+		{"SHOW CREATE TABLE t", true},
+		{"DESCRIBE t", true},
+		{"EXPLAIN SELECT 1", true},
+		{"TABLE t", true},
+		{"VALUES ROW(1)", true},
+		{"SELECT * FROM t INTO OUTFILE '/tmp/t.csv'", false},
+		{"select * from t into dumpfile '/tmp/t'", false},
+		{"WITH c AS (SELECT 1) DELETE FROM t", false},
+		{"/* SELECT */ DELETE FROM t", false},
+		{"INSERT INTO t SELECT 1", false},
+		{"ANALYZE TABLE t", false},
+		{"OPTIMIZE TABLE t", false},
+		{"REPAIR TABLE t", false},
+		{"CALL p()", false},
+		{"SET @a = 1", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsRead(tt.stmt); got != tt.want {
+			t.Errorf("IsRead(%q) = %v, want %v", tt.stmt, got, tt.want)
+		}
+	}
+}
+
+func TestSkipQuoted(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{`'abc' x`, 5},
+		{`'a''b' x`, 6},
+		{`'a\'b' x`, 6},
+		{`"a\"b" x`, 6},
+		{"`a``b` x", 6},
+		{"`a\\` x", 4},
+	}
+	for _, tt := range tests {
+		got, err := SkipQuoted(tt.s, 0)
+		if err != nil || got != tt.want {
+			t.Errorf("SkipQuoted(%q) = %d, %v, want %d", tt.s, got, err, tt.want)
+		}
+	}
+	if _, err := SkipQuoted(`'open\'`, 0); err == nil {
+		t.Error("SkipQuoted accepted an unterminated string")
+	}
+}
@@ -7,8 +7,6 @@ import (
     "fmt"
 
 	"github.com/gofiber/fiber/v2"
-    "gorm.io/driver/mysql"
-    "gorm.io/gorm"
 )
 
 type ConnectionHandler struct{
@@ -27,12 +25,33 @@ func (h *ConnectionHandler) List(c *fiber.Ctx) error {
 	// Strip passwords for security
 	safe := make([]fiber.Map, len(conns))
 	for i, conn := range conns {
+		var ssh fiber.Map
+		if conn.SSH != nil {
+			ssh = fiber.Map{
+				"host": conn.SSH.Host,
+				"port": conn.SSH.Port,
+				"user": conn.SSH.User,
+				"key_path": conn.SSH.KeyPath,
+				"use_agent": conn.SSH.UseAgent,
+				"known_hosts": conn.SSH.KnownHosts,
+				"has_passphrase": conn.SSH.Passphrase != "" || conn.SSH.PassphraseEncrypted != "",
+			}
+		}
 		safe[i] = fiber.Map{
 			"name": conn.Name,
 			"host": conn.Host,
 			"port": conn.Port,
 			"user": conn.User,
 			"database": conn.Database,
+			"read_only": conn.ReadOnly,
+			"password_env": conn.PasswordEnv,
+			"password_file": conn.PasswordFile,
+			"has_password": conn.Password != "" || conn.PasswordEnv != "" || conn.PasswordFile != "",
+			"ssl_mode": conn.SSLMode,
+			"ssl_ca": conn.SSLCA,
+			"ssl_cert": conn.SSLCert,
+			"ssl_key": conn.SSLKey,
+			"ssh": ssh,
             "type": "mysql", // Default for now
 		}
 	}
@@ -44,11 +63,18 @@ func (h *ConnectionHandler) Save(c *fiber.Ctx) error {
 	if err := c.BodyParser(&conn); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid connection data"})
 	}
+	if err := conn.CheckRequest(); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 
 	if conn.Name == "" { conn.Name = "default" }
 	if conn.Host == "" { conn.Host = "127.0.0.1" }
 	if conn.Port == 0 { conn.Port = 3306 }
 
+	if _, err := conn.TLSConfig(); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	if err := config.AddConnection(conn); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -62,22 +88,31 @@ func (h *ConnectionHandler) Apply(c *fiber.Ctx) error {
 	if err := c.BodyParser(&conn); err != nil {
 		return c.Status(400).JSON(fiber.Map{"status": "error", "message": "Invalid request body format"})
 	}
+	if err := conn.CheckRequest(); err != nil {
+		return c.Status(400).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
     
     // Cari password di saved connections jika tidak dikirim (keamanan frontend)
-    if conn.Password == "" {
-        saved, _ := config.GetConnections()
-        for _, sc := range saved {
-            if sc.Name == conn.Name {
-                conn.Password = sc.Password
-                break
+    // A saved read_only flag cannot be dropped by leaving it out of the request
+    saved, _ := config.GetConnections()
+    for _, sc := range saved {
+        if sc.Name == conn.Name {
+            if conn.Password == "" {
+                conn.Password, conn.PasswordEnv, conn.PasswordFile = sc.Password, sc.PasswordEnv, sc.PasswordFile
             }
+            conn.ReadOnly = conn.ReadOnly || sc.ReadOnly
+            conn.SSH.KeepPassphrase(sc.SSH)
+            break
         }
     }
 
-    dsn := config.BuildDSN(&conn)
+    dsn, err := config.BuildDSN(&conn)
+    if err != nil {
+        return c.Status(400).JSON(fiber.Map{"status": "error", "message": err.Error()})
+    }
     fmt.Printf("Attempting to Apply connection: %s@%s:%d (DB: %s)\n", conn.User, conn.Host, conn.Port, conn.Database)
     
-    database.Connect(dsn)
+    database.Connect(dsn, conn.SSH)
     
     // database.DB sekarang dijamin nil jika gagal (karena fix kita di database.go)
     if database.DB == nil {
@@ -85,9 +120,10 @@ func (h *ConnectionHandler) Apply(c *fiber.Ctx) error {
     }
 
     // Hanya update repository jika koneksi benar-benar sehat
-    h.repo.SetDB(database.DB)
+    h.repo.SetDB(database.DB, conn.ReadOnly)
+    config.SetActiveConnectionName(conn.Name)
 
-    return c.JSON(fiber.Map{"status": "ok", "message": "Connection applied successfully"})
+    return c.JSON(fiber.Map{"status": "ok", "message": "Connection applied successfully", "read_only": conn.ReadOnly})
 }
 
 func (h *ConnectionHandler) Test(c *fiber.Ctx) error {
@@ -95,21 +131,30 @@ func (h *ConnectionHandler) Test(c *fiber.Ctx) error {
 	if err := c.BodyParser(&conn); err != nil {
 		return c.Status(400).JSON(fiber.Map{"status": "error", "message": "Invalid request body format"})
 	}
+	if err := conn.CheckRequest(); err != nil {
+		return c.Status(400).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
 
 	if conn.Host == "" { conn.Host = "127.0.0.1" }
 	if conn.Port == 0 { conn.Port = 3306 }
 
-	dsn := config.BuildDSN(&conn)
+	dsn, err := config.BuildDSN(&conn)
+    if err != nil {
+        return c.Status(400).JSON(fiber.Map{"status": "error", "message": err.Error()})
+    }
     fmt.Printf("Testing connection: %s@%s:%d\n", conn.User, conn.Host, conn.Port)
     
     if conn.User == "" {
         return c.Status(400).JSON(fiber.Map{"status": "error", "message": "Username is required"})
     }
 
-    db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+    db, tunnel, err := database.Open(dsn, conn.SSH)
 	if err != nil {
         return c.Status(400).JSON(fiber.Map{"status": "error", "message": fmt.Sprintf("Network connection failed: %v", err)})
     }
+    if tunnel != nil {
+        defer tunnel.Close()
+    }
     
     // Cek apakah DB yang dikembalikan punya error internal
     if db.Error != nil {
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestContext gives every request a context (c.UserContext()) that is
+// cancelled when the handler returns, so queries started for a request
+// do not outlive it. fasthttp does not report client disconnects; long
+// console queries are stopped through their timeout or the cancel
+// endpoint instead.
+func RequestContext(c *fiber.Ctx) error {
+	ctx, cancel := context.WithCancel(c.UserContext())
+	defer cancel()
+	c.SetUserContext(ctx)
+	return c.Next()
+}
@@ -2,7 +2,16 @@ package handlers
 
 import (
 	"backend/internal/domain"
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -15,23 +24,325 @@ func NewDataHandler(service domain.DataService) *DataHandler {
 	return &DataHandler{service: service}
 }
 
-func (h *DataHandler) GetData(c *fiber.Ctx) error {
-	table := c.Query("table")
+const (
+	maxPageSize = 1000
+	// defaultMaxValue is the longest text/binary value sent inline
+	defaultMaxValue = 2048
+)
+
+// parseDataQuery reads page, limit, filter and sort from the query string.
+//
+//	filter={"logic":"or","conditions":[{"column":"age","op":"gte","value":18}],"groups":[...]}
+//	sort=last_name,-created_at   (a leading "-" sorts descending)
+//	paging=cursor or cursor=<next_cursor>   keyset pagination instead of page
+//	count=exact|estimate|none
+//	max_value=2048   truncate longer values (0 = never)
+func parseDataQuery(c *fiber.Ctx) (domain.DataQuery, error) {
 	page := c.QueryInt("page", 1)
+	if page < 1 { page = 1 }
 	limit := c.QueryInt("limit", 50)
-	offset := (page - 1) * limit
+	if limit < 1 { limit = 50 }
+	if limit > maxPageSize { limit = maxPageSize }
+
+	q := domain.DataQuery{Limit: limit, Offset: (page - 1) * limit}
+	q.MaxValueSize = c.QueryInt("max_value", defaultMaxValue)
+	if q.MaxValueSize < 0 { q.MaxValueSize = defaultMaxValue }
 
-	data, err := h.service.GetData(context.Background(), table, limit, offset)
-	if err != nil { return c.Status(500).JSON(fiber.Map{"error": err.Error()}) }
+	q.Cursor = c.Query("cursor")
+	q.Keyset = q.Cursor != "" || c.Query("paging") == "cursor"
+	q.Count = c.Query("count", domain.CountExact)
+	if q.Keyset {
+		q.Count = c.Query("count", domain.CountEstimate)
+	}
+	switch q.Count {
+	case domain.CountExact, domain.CountEstimate, domain.CountNone:
+	default:
+		return q, fmt.Errorf("count must be exact, estimate or none")
+	}
+
+	if raw := c.Query("filter"); raw != "" {
+		var f domain.FilterGroup
+		dec := json.NewDecoder(strings.NewReader(raw))
+		dec.UseNumber() // keep big integer values exact
+		if err := dec.Decode(&f); err != nil {
+			return q, fmt.Errorf("invalid filter: %v", err)
+		}
+		q.Filter = &f
+	}
+	for _, s := range strings.Split(c.Query("sort"), ",") {
+		s = strings.TrimSpace(s)
+		if s == "" { continue }
+		if strings.HasPrefix(s, "-") {
+			q.Sort = append(q.Sort, domain.SortField{Column: s[1:], Desc: true})
+		} else {
+			q.Sort = append(q.Sort, domain.SortField{Column: strings.TrimPrefix(s, "+")})
+		}
+	}
+	return q, nil
+}
+
+func (h *DataHandler) GetData(c *fiber.Ctx) error {
+	table := c.Query("table")
+	if table == "" { return c.Status(400).JSON(fiber.Map{"error": "table name required"}) }
+	q, err := parseDataQuery(c)
+	if err != nil { return c.Status(400).JSON(fiber.Map{"error": err.Error()}) }
+
+	data, err := h.service.GetData(c.UserContext(), table, q)
+	if err != nil { return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()}) }
 	return c.JSON(data)
 }
 
+// GetRelated returns a row with the parent rows its foreign keys point to
+// and the child rows referencing it.
+// Query: table, pk=42 (single-column key) or key={"a":1,"b":2}, limit
+func (h *DataHandler) GetRelated(c *fiber.Ctx) error {
+	q := domain.RelatedQuery{
+		Table:        c.Query("table"),
+		PK:           c.Query("pk"),
+		Limit:        c.QueryInt("limit", 0),
+		MaxValueSize: c.QueryInt("max_value", defaultMaxValue),
+	}
+	if q.Table == "" { return c.Status(400).JSON(fiber.Map{"error": "table name required"}) }
+	if raw := c.Query("key"); raw != "" {
+		dec := json.NewDecoder(strings.NewReader(raw))
+		dec.UseNumber()
+		if err := dec.Decode(&q.Key); err != nil { return c.Status(400).JSON(fiber.Map{"error": "key must be a JSON object"}) }
+	}
+
+	related, err := h.service.GetRelated(c.UserContext(), q)
+	if err != nil { return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()}) }
+	return c.JSON(related)
+}
+
+// GetValue returns one complete cell value, the target of the url on
+// truncated values. Query: table, column, key={"id":42}
+func (h *DataHandler) GetValue(c *fiber.Ctx) error {
+	table, column := c.Query("table"), c.Query("column")
+	if table == "" || column == "" { return c.Status(400).JSON(fiber.Map{"error": "table and column required"}) }
+	var key map[string]interface{}
+	dec := json.NewDecoder(strings.NewReader(c.Query("key")))
+	dec.UseNumber() // keep big integer keys exact
+	if err := dec.Decode(&key); err != nil { return c.Status(400).JSON(fiber.Map{"error": "key must be a JSON object"}) }
+
+	cell, err := h.service.GetValue(c.UserContext(), table, column, key)
+	if err != nil { return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()}) }
+	if cell.Data == nil { return c.SendStatus(fiber.StatusNoContent) }
+	if cell.Binary {
+		c.Set(fiber.HeaderContentType, "application/octet-stream")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+strings.ReplaceAll(table+"."+column, `"`, "_")+`.bin"`)
+	} else {
+		c.Set(fiber.HeaderContentType, "text/plain; charset=utf-8")
+	}
+	return c.Send(cell.Data)
+}
+
 func (h *DataHandler) Insert(c *fiber.Ctx) error {
 	table := c.Query("table")
 	var data map[string]interface{}
-	if err := c.BodyParser(&data); err != nil { return c.Status(400).JSON(fiber.Map{"error": "invalid json"}) }
-	if err := h.service.Insert(context.Background(), table, data); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	if err := decodeJSON(c, &data); err != nil { return c.Status(400).JSON(fiber.Map{"error": "invalid json"}) }
+	if err := h.service.Insert(c.UserContext(), table, data); err != nil {
+		return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(fiber.Map{"message": "data inserted"})
 }
+
+// Update changes one row identified by its primary key.
+// Body: {"key": {"id": 42}, "values": {"name": "new"}}
+func (h *DataHandler) Update(c *fiber.Ctx) error {
+	table := c.Query("table")
+	if table == "" { return c.Status(400).JSON(fiber.Map{"error": "table name required"}) }
+	var change domain.RowChange
+	if err := decodeJSON(c, &change); err != nil { return c.Status(400).JSON(fiber.Map{"error": "invalid json"}) }
+
+	affected, err := h.service.Update(c.UserContext(), table, change)
+	if err != nil { return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()}) }
+	return c.JSON(fiber.Map{"message": "row updated", "affected": affected})
+}
+
+// decodeJSON reads the request body keeping numbers as json.Number, so big
+// integer keys and values are not rounded through float64
+func decodeJSON(c *fiber.Ctx, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(c.Body()))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// Delete removes one row identified by its primary key.
+// Body: {"key": {"id": 42}} or {"row": {...}} for tables without a primary key
+func (h *DataHandler) Delete(c *fiber.Ctx) error {
+	table := c.Query("table")
+	if table == "" { return c.Status(400).JSON(fiber.Map{"error": "table name required"}) }
+	var change domain.RowChange
+	if err := decodeJSON(c, &change); err != nil { return c.Status(400).JSON(fiber.Map{"error": "invalid json"}) }
+
+	affected, err := h.service.Delete(c.UserContext(), table, change)
+	if err != nil { return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()}) }
+	return c.JSON(fiber.Map{"message": "row deleted", "affected": affected})
+}
+
+// Changeset applies inserts, updates and deletes across tables atomically.
+// On failure nothing is committed and the per-operation results show which
+// operation failed.
+func (h *DataHandler) Changeset(c *fiber.Ctx) error {
+	var cs domain.Changeset
+	if err := decodeJSON(c, &cs); err != nil { return c.Status(400).JSON(fiber.Map{"error": "invalid json"}) }
+
+	result, err := h.service.ApplyChangeset(c.UserContext(), cs)
+	if err != nil {
+		if result != nil {
+			return c.Status(409).JSON(fiber.Map{"error": err.Error(), "committed": false, "results": result.Results})
+		}
+		return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(result)
+}
+
+// exportContext is the context for an export. The body is streamed after
+// the handler has returned and the request context is cancelled, so
+// exports get their own context, cancelled by streamExport once written.
+func exportContext() (context.Context, context.CancelFunc) {
+	return context.WithCancel(context.Background())
+}
+
+func streamExport(c *fiber.Ctx, exp *domain.Export, done context.CancelFunc) error {
+	c.Set(fiber.HeaderContentType, exp.ContentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+strings.ReplaceAll(exp.Filename, `"`, "_")+`"`)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer done()
+		if err := exp.Write(w); err != nil {
+			// headers are already sent; all we can do is cut the stream short
+			log.Printf("Export %s failed: %v", exp.Filename, err)
+		}
+		w.Flush()
+	})
+	return nil
+}
+
+// Export streams a whole table (?format=csv|json|ndjson|xlsx), honouring the
+// same filter and sort parameters as GetData. With format=xlsx, table may
+// list several tables (table=users,orders) to get one sheet per table.
+func (h *DataHandler) Export(c *fiber.Ctx) error {
+	table := c.Query("table")
+	if table == "" { return c.Status(400).JSON(fiber.Map{"error": "table name required"}) }
+	q, err := parseDataQuery(c)
+	if err != nil { return c.Status(400).JSON(fiber.Map{"error": err.Error()}) }
+
+	format := c.Query("format", "csv")
+	if format == "xlsx" {
+		var tables []string
+		for _, t := range strings.Split(table, ",") {
+			if t = strings.TrimSpace(t); t != "" { tables = append(tables, t) }
+		}
+		ctx, done := exportContext()
+		exp, err := h.service.ExportWorkbook(ctx, tables, q)
+		if err != nil { done(); return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()}) }
+		return streamExport(c, exp, done)
+	}
+
+	ctx, done := exportContext()
+	exp, err := h.service.ExportTable(ctx, table, q, format)
+	if err != nil { done(); return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()}) }
+	return streamExport(c, exp, done)
+}
+
+// ExportQuery streams the result of an ad-hoc SELECT.
+// Body: {"query": "SELECT ...", "format": "csv"}
+func (h *DataHandler) ExportQuery(c *fiber.Ctx) error {
+	type Req struct {
+		Query  string `json:"query"`
+		Format string `json:"format"`
+	}
+	var req Req
+	if err := c.BodyParser(&req); err != nil { return c.Status(400).JSON(fiber.Map{"error": "invalid json"}) }
+
+	ctx, done := exportContext()
+	exp, err := h.service.ExportQuery(ctx, req.Query, req.Format)
+	if err != nil { done(); return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()}) }
+	return streamExport(c, exp, done)
+}
+
+// Import loads rows from a CSV, NDJSON or XLSX upload into a table, either
+// as a multipart "file" field or as the raw request body.
+//
+//	format=csv|ndjson|xlsx         defaults to the file extension, then csv
+//	sheet=Customers                worksheet of an xlsx file (default: first)
+//	mapping={"E-mail":"email","Notes":""}   source field -> column ("" skips)
+//	chunk=500                      rows per INSERT statement
+//	dry_run=true                   validate and count without writing
+func (h *DataHandler) Import(c *fiber.Ctx) error {
+	table := c.Query("table")
+	if table == "" { return c.Status(400).JSON(fiber.Map{"error": "table name required"}) }
+
+	opts := domain.ImportOptions{
+		Format:    formOrQuery(c, "format"),
+		Sheet:     formOrQuery(c, "sheet"),
+		ChunkSize: c.QueryInt("chunk", 0),
+		DryRun:    c.QueryBool("dry_run", false),
+	}
+	if raw := formOrQuery(c, "mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &opts.Mapping); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("invalid mapping: %v", err)})
+		}
+	}
+
+	var body io.Reader = bytes.NewReader(c.Body())
+	if fh, err := c.FormFile("file"); err == nil {
+		f, err := fh.Open()
+		if err != nil { return c.Status(400).JSON(fiber.Map{"error": err.Error()}) }
+		defer f.Close()
+		body = f
+		if opts.Format == "" {
+			opts.Format = formatFromFilename(fh.Filename)
+		}
+	} else if len(c.Body()) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "send the data as a multipart \"file\" field or as the request body"})
+	}
+
+	result, err := h.service.Import(c.UserContext(), table, body, opts)
+	if err != nil { return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()}) }
+	return c.JSON(result)
+}
+
+func formOrQuery(c *fiber.Ctx, key string) string {
+	if v := c.FormValue(key); v != "" {
+		return v
+	}
+	return c.Query(key)
+}
+
+func formatFromFilename(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ndjson", ".jsonl":
+		return "ndjson"
+	case ".json":
+		return "json"
+	case ".xlsx":
+		return "xlsx"
+	}
+	return ""
+}
+
+// Generate fills a table with synthetic rows inferred from its columns.
+// Query: rows=N (required), parent_rows=N for empty parent tables, seed=N
+// for reproducible output.
+func (h *DataHandler) Generate(c *fiber.Ctx) error {
+	table := c.Query("table")
+	if table == "" { return c.Status(400).JSON(fiber.Map{"error": "table name required"}) }
+	seed, err := strconv.ParseInt(c.Query("seed", "0"), 10, 64)
+	if err != nil { return c.Status(400).JSON(fiber.Map{"error": "seed must be an integer"}) }
+
+	opts := domain.GenerateOptions{
+		Rows:       c.QueryInt("rows", 0),
+		ParentRows: c.QueryInt("parent_rows", 0),
+		Seed:       seed,
+	}
+	result, err := h.service.Generate(c.UserContext(), table, opts)
+	if err != nil {
+		if result != nil && len(result.Tables) > 0 {
+			// parents generated before the failure are committed
+			return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error(), "tables": result.Tables})
+		}
+		return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(result)
+}
@@ -1,22 +1,25 @@
 package handlers
 
 import (
+	"backend/internal/config"
 	"backend/internal/domain"
-	"context"
+	"fmt"
+	"log"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type DatabaseHandler struct {
 	service domain.DatabaseService
+	queries domain.QueryService // console history
 }
 
-func NewDatabaseHandler(service domain.DatabaseService) *DatabaseHandler {
-	return &DatabaseHandler{service: service}
+func NewDatabaseHandler(service domain.DatabaseService, queries domain.QueryService) *DatabaseHandler {
+	return &DatabaseHandler{service: service, queries: queries}
 }
 
 func (h *DatabaseHandler) List(c *fiber.Ctx) error {
-	dbs, err := h.service.List(context.Background())
+	dbs, err := h.service.List(c.UserContext())
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -27,30 +30,71 @@ func (h *DatabaseHandler) Create(c *fiber.Ctx) error {
 	type Req struct { Name string `json:"name"` }
 	var req Req
 	if err := c.BodyParser(&req); err != nil { return c.Status(400).JSON(fiber.Map{"error": "invalid json"}) }
-	if err := h.service.Create(context.Background(), req.Name); err != nil { 
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	if err := h.service.Create(c.UserContext(), req.Name); err != nil { 
+		return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(fiber.Map{"message": "database created"})
 }
 
 func (h *DatabaseHandler) Drop(c *fiber.Ctx) error {
 	name := c.Query("name")
-	if err := h.service.Drop(context.Background(), name); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	if err := h.service.Drop(c.UserContext(), name); err != nil {
+		return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 	return c.JSON(fiber.Map{"message": "database dropped"})
 }
 
+// ExecuteQuery runs a statement or a script from the SQL console, one
+// result per statement.
+// Body: {"query": "SELECT ...; UPDATE ...", "database": "shop", "max_rows": 1000,
+// "continue_on_error": false, "query_id": "<client id>", "timeout_seconds": 60}
+// A script stopped by a failing statement is answered with 400 and the
+// results so far; with continue_on_error the failures are counted instead.
 func (h *DatabaseHandler) ExecuteQuery(c *fiber.Ctx) error {
-	type Req struct { Query string `json:"query"` }
-	var req Req
+	var req domain.ConsoleRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid json"})
 	}
 
-	if err := h.service.Execute(context.Background(), req.Query); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	result, err := h.service.Execute(c.UserContext(), req)
+	if herr := h.queries.Record(c.UserContext(), config.ActiveConnectionName(), req, result, err); herr != nil {
+		log.Printf("Recording query history failed: %v", herr)
+	}
+	if err != nil {
+		return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	failed := 0
+	for _, r := range result.Results {
+		if r.Error == "" {
+			continue
+		}
+		if !req.ContinueOnError {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("statement %d (line %d): %s", r.Index+1, r.Line, r.Error), "results": result.Results, "duration_ms": result.DurationMs})
+		}
+		failed++
+	}
+	return c.JSON(fiber.Map{"message": "query executed successfully", "results": result.Results, "failed": failed, "duration_ms": result.DurationMs})
+}
+
+// Explain returns the normalized plan of a statement, annotated with the
+// schema tables it reads. analyze also executes it (SELECT only).
+// Body: {"query": "SELECT ...", "database": "shop", "analyze": true}
+func (h *DatabaseHandler) Explain(c *fiber.Ctx) error {
+	var req domain.ExplainRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid json"})
 	}
+	result, err := h.service.Explain(c.UserContext(), req)
+	if err != nil {
+		return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(result)
+}
 
-	return c.JSON(fiber.Map{"message": "query executed successfully"})
+// Cancel stops a running console query by the query_id it was started with
+func (h *DatabaseHandler) Cancel(c *fiber.Ctx) error {
+	if err := h.service.Cancel(c.UserContext(), c.Params("id")); err != nil {
+		return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "query cancelled"})
 }
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"backend/internal/domain"
+	"errors"
+)
+
+// statusFor maps service errors to HTTP status codes
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return 404
+	case errors.Is(err, domain.ErrInvalidInput):
+		return 400
+	case errors.Is(err, domain.ErrReadOnly):
+		return 403
+	}
+	return 500
+}
@@ -1,8 +1,9 @@
 package handlers
 
 import (
+	"backend/internal/config"
 	"backend/internal/domain"
-	"context"
+	"fmt"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -15,17 +16,79 @@ func NewLayoutHandler(service domain.LayoutService) *LayoutHandler {
 	return &LayoutHandler{service: service}
 }
 
+// connectionName is the saved connection that layouts and views belong
+// to: ?connection= or the active one
+func connectionName(c *fiber.Ctx) string {
+	return c.Query("connection", config.ActiveConnectionName())
+}
+
+// liveConnection is connectionName for endpoints that read the schema of
+// the connected server, where only the active connection makes sense
+func liveConnection(c *fiber.Ctx) (string, error) {
+	name, active := connectionName(c), config.ActiveConnectionName()
+	if name != active {
+		return "", fmt.Errorf("connection %q is not the active connection (%s); apply it first: %w", name, active, domain.ErrInvalidInput)
+	}
+	return name, nil
+}
+
+// layoutKey reads ?db=, ?connection= and ?name= (the last two are optional)
+func layoutKey(c *fiber.Ctx) (domain.LayoutKey, bool) {
+	key := domain.LayoutKey{
+		Connection: connectionName(c),
+		Database:   c.Query("db"),
+		Name:       c.Query("name", domain.DefaultLayoutName),
+	}
+	return key, key.Database != ""
+}
+
 func (h *LayoutHandler) Save(c *fiber.Ctx) error {
-	var body map[string]interface{}
-	if err := c.BodyParser(&body); err != nil { return c.Status(400).JSON(fiber.Map{"error": "invalid json"}) }
-	if err := h.service.Save(context.Background(), body); err != nil {
+	key, ok := layoutKey(c)
+	if !ok { return c.Status(400).JSON(fiber.Map{"error": "db name required"}) }
+	body, err := domain.DecodeLayout(c.Body())
+	if err != nil { return c.Status(400).JSON(fiber.Map{"error": err.Error()}) }
+	if err := body.Validate(); err != nil { return c.Status(400).JSON(fiber.Map{"error": err.Error()}) }
+	if err := h.service.Save(c.UserContext(), key, body); err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
-	return c.JSON(fiber.Map{"message": "layout saved"})
+	return c.JSON(fiber.Map{"message": "layout saved", "name": key.Name})
 }
 
 func (h *LayoutHandler) Get(c *fiber.Ctx) error {
-	layout, err := h.service.Get(context.Background())
+	key, ok := layoutKey(c)
+	if !ok { return c.Status(400).JSON(fiber.Map{"error": "db name required"}) }
+	layout, err := h.service.Get(c.UserContext(), key)
 	if err != nil { return c.Status(500).JSON(fiber.Map{"error": err.Error()}) }
 	return c.JSON(layout)
 }
+
+func (h *LayoutHandler) Delete(c *fiber.Ctx) error {
+	key, ok := layoutKey(c)
+	if !ok { return c.Status(400).JSON(fiber.Map{"error": "db name required"}) }
+	if err := h.service.Delete(c.UserContext(), key); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "layout deleted"})
+}
+
+// List returns the names of the layouts saved for a database
+func (h *LayoutHandler) List(c *fiber.Ctx) error {
+	key, ok := layoutKey(c)
+	if !ok { return c.Status(400).JSON(fiber.Map{"error": "db name required"}) }
+	names, err := h.service.List(c.UserContext(), key.Connection, key.Database)
+	if err != nil { return c.Status(500).JSON(fiber.Map{"error": err.Error()}) }
+	return c.JSON(names)
+}
+
+// Auto computes positions for every table (?algorithm=layered|force|grid).
+// The result is stored unless ?save=false.
+func (h *LayoutHandler) Auto(c *fiber.Ctx) error {
+	key, ok := layoutKey(c)
+	if !ok { return c.Status(400).JSON(fiber.Map{"error": "db name required"}) }
+	if _, err := liveConnection(c); err != nil { return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()}) }
+	algorithm := c.Query("algorithm", "layered")
+
+	layout, err := h.service.AutoLayout(c.UserContext(), key, algorithm, c.QueryBool("save", true))
+	if err != nil { return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()}) }
+	return c.JSON(layout)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"backend/internal/config"
+	"backend/internal/domain"
+	"bytes"
+	"encoding/json"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// QueryHandler serves the console history and saved queries of the active
+// connection
+type QueryHandler struct {
+	service domain.QueryService
+}
+
+func NewQueryHandler(service domain.QueryService) *QueryHandler {
+	return &QueryHandler{service: service}
+}
+
+// History searches past console runs, newest first.
+// ?db=shop&q=orders&status=ok|error&limit=50&offset=0
+func (h *QueryHandler) History(c *fiber.Ctx) error {
+	q := domain.HistoryQuery{
+		Database: c.Query("db"),
+		Search:   c.Query("q"),
+		Status:   c.Query("status"),
+		Limit:    c.QueryInt("limit", 0),
+		Offset:   c.QueryInt("offset", 0),
+	}
+	page, err := h.service.History(c.UserContext(), config.ActiveConnectionName(), q)
+	if err != nil {
+		return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(page)
+}
+
+func (h *QueryHandler) ClearHistory(c *fiber.Ctx) error {
+	if err := h.service.ClearHistory(c.UserContext(), config.ActiveConnectionName()); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "history cleared"})
+}
+
+// queryName is the :name route parameter; names may contain spaces and
+// arrive percent-encoded
+func queryName(c *fiber.Ctx) string {
+	name, err := url.PathUnescape(c.Params("name"))
+	if err != nil {
+		return c.Params("name")
+	}
+	return name
+}
+
+// List returns the saved queries, filtered by ?tag= and ?q=
+func (h *QueryHandler) List(c *fiber.Ctx) error {
+	queries, err := h.service.ListSaved(c.UserContext(), config.ActiveConnectionName(), c.Query("tag"), c.Query("q"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(queries)
+}
+
+func (h *QueryHandler) Get(c *fiber.Ctx) error {
+	q, err := h.service.GetSaved(c.UserContext(), config.ActiveConnectionName(), queryName(c))
+	if err != nil {
+		return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(q)
+}
+
+// Save creates or replaces a saved query by name.
+// Body: {"name": "orders by customer", "query": "SELECT ...", "database": "shop",
+// "tags": ["support"], "parameters": [{"name": "customer_id", "type": "int", "required": true}]}
+func (h *QueryHandler) Save(c *fiber.Ctx) error {
+	var q domain.SavedQuery
+	if err := c.BodyParser(&q); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid json"})
+	}
+	saved, err := h.service.Save(c.UserContext(), config.ActiveConnectionName(), q)
+	if err != nil {
+		return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(saved)
+}
+
+func (h *QueryHandler) Delete(c *fiber.Ctx) error {
+	if err := h.service.DeleteSaved(c.UserContext(), config.ActiveConnectionName(), queryName(c)); err != nil {
+		return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "query deleted"})
+}
+
+// Run executes a saved query with bound parameters; the SQL text itself
+// cannot be changed by the caller.
+// Body: {"params": {"customer_id": 42}, "max_rows": 100}
+func (h *QueryHandler) Run(c *fiber.Ctx) error {
+	var req struct {
+		Params  map[string]interface{} `json:"params"`
+		MaxRows int                    `json:"max_rows"`
+	}
+	if len(bytes.TrimSpace(c.Body())) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(c.Body()))
+		dec.UseNumber() // keep large integers exact
+		if err := dec.Decode(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid json"})
+		}
+	}
+	result, err := h.service.Run(c.UserContext(), config.ActiveConnectionName(), queryName(c), req.Params, req.MaxRows)
+	if err != nil {
+		return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	for _, r := range result.Results {
+		if r.Error != "" {
+			return c.Status(400).JSON(fiber.Map{"error": r.Error, "results": result.Results, "duration_ms": result.DurationMs})
+		}
+	}
+	return c.JSON(fiber.Map{"results": result.Results, "duration_ms": result.DurationMs})
+}
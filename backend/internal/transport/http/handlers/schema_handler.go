@@ -2,17 +2,17 @@ package handlers
 
 import (
 	"backend/internal/domain"
-	"context"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type SchemaHandler struct {
 	service domain.SyncService
+	views   domain.ViewService
 }
 
-func NewSchemaHandler(service domain.SyncService) *SchemaHandler {
-	return &SchemaHandler{service: service}
+func NewSchemaHandler(service domain.SyncService, views domain.ViewService) *SchemaHandler {
+	return &SchemaHandler{service: service, views: views}
 }
 
 func (h *SchemaHandler) GetSchema(c *fiber.Ctx) error {
@@ -21,7 +21,20 @@ func (h *SchemaHandler) GetSchema(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "db name required"})
 	}
 
-	schema, err := h.service.GetSchema(context.Background(), dbName)
+	// ?view= narrows the schema down to a saved diagram view
+	if view := c.Query("view"); view != "" {
+		connection, err := liveConnection(c)
+		if err != nil {
+			return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+		schema, err := h.views.Schema(c.UserContext(), connection, dbName, view)
+		if err != nil {
+			return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(schema)
+	}
+
+	schema, err := h.service.GetSchema(c.UserContext(), dbName)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -35,8 +48,8 @@ func (h *SchemaHandler) SyncBatch(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request format"})
 	}
 
-	if err := h.service.SyncBatch(context.Background(), dbName, reqs); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	if err := h.service.SyncBatch(c.UserContext(), dbName, reqs); err != nil {
+		return c.Status(statusFor(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.JSON(fiber.Map{"message": "All tables synced successfully"})
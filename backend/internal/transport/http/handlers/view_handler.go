@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"backend/internal/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ViewHandler struct {
+	service domain.ViewService
+}
+
+func NewViewHandler(service domain.ViewService) *ViewHandler {
+	return &ViewHandler{service: service}
+}
+
+func (h *ViewHandler) List(c *fiber.Ctx) error {
+	dbName := c.Query("db")
+	if dbName == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "db name required"})
+	}
+	views, err := h.service.List(c.UserContext(), connectionName(c), dbName)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(views)
+}
+
+// Save creates or replaces a view. Its layout is the layout with the same name.
+func (h *ViewHandler) Save(c *fiber.Ctx) error {
+	dbName := c.Query("db")
+	if dbName == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "db name required"})
+	}
+	var view domain.DiagramView
+	if err := c.BodyParser(&view); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid json"})
+	}
+	if err := view.Validate(); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := h.service.Save(c.UserContext(), connectionName(c), dbName, view); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "view saved", "name": view.Name})
+}
+
+func (h *ViewHandler) Delete(c *fiber.Ctx) error {
+	dbName := c.Query("db")
+	name := c.Query("name")
+	if dbName == "" || name == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "db and view name required"})
+	}
+	if err := h.service.Delete(c.UserContext(), connectionName(c), dbName, name); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "view deleted"})
+}
@@ -13,14 +13,19 @@ func SetupRoutes(
 	dbService domain.DatabaseService,
 	dataService domain.DataService,
 	layoutService domain.LayoutService,
+	viewService domain.ViewService,
+	queryService domain.QueryService,
 	repo domain.SchemaRepository,
 ) {
 	api := app.Group("/api")
+	api.Use(_handlers.RequestContext)
 
-	schemaH := _handlers.NewSchemaHandler(syncService)
-	dbH := _handlers.NewDatabaseHandler(dbService)
+	schemaH := _handlers.NewSchemaHandler(syncService, viewService)
+	dbH := _handlers.NewDatabaseHandler(dbService, queryService)
 	dataH := _handlers.NewDataHandler(dataService)
 	layoutH := _handlers.NewLayoutHandler(layoutService)
+	viewH := _handlers.NewViewHandler(viewService)
+	queryH := _handlers.NewQueryHandler(queryService)
 
 	api.Get("/health", _handlers.HealthCheck)
 
@@ -33,14 +38,42 @@ func SetupRoutes(
 	api.Post("/databases", dbH.Create)
 	api.Delete("/databases", dbH.Drop)
 	api.Post("/databases/query", dbH.ExecuteQuery)
+	api.Post("/databases/explain", dbH.Explain)
+	api.Post("/queries/:id/cancel", dbH.Cancel)
+
+	// Query History & Saved Queries
+	api.Get("/history", queryH.History)
+	api.Delete("/history", queryH.ClearHistory)
+	api.Get("/queries", queryH.List)
+	api.Post("/queries", queryH.Save)
+	api.Get("/queries/:name", queryH.Get)
+	api.Delete("/queries/:name", queryH.Delete)
+	api.Post("/queries/:name/run", queryH.Run)
 
 	// Data Browser
 	api.Get("/data", dataH.GetData)
+	api.Get("/data/value", dataH.GetValue)
+	api.Get("/data/related", dataH.GetRelated)
 	api.Post("/data", dataH.Insert)
+	api.Put("/data", dataH.Update)
+	api.Delete("/data", dataH.Delete)
+	api.Post("/data/changeset", dataH.Changeset)
+	api.Get("/data/export", dataH.Export)
+	api.Post("/data/export", dataH.ExportQuery)
+	api.Post("/data/import", dataH.Import)
+	api.Post("/data/generate", dataH.Generate)
 
 	// Layout Persistence
 	api.Get("/layout", layoutH.Get)
 	api.Post("/layout", layoutH.Save)
+	api.Delete("/layout", layoutH.Delete)
+	api.Get("/layouts", layoutH.List)
+	api.Post("/layout/auto", layoutH.Auto)
+
+	// Diagram Views (subject areas)
+	api.Get("/views", viewH.List)
+	api.Post("/views", viewH.Save)
+	api.Delete("/views", viewH.Delete)
 
 	// Connection Management
 	connH := _handlers.NewConnectionHandler(repo)